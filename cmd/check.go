@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/urfave/cli/v2"
+)
+
+// driftExitCode is the exit code of the check command when the topology differs from the baseline.
+const driftExitCode = 3
+
+// Check compares the topology of a source, the live cluster by default, with a baseline file, and fails with a report
+// of the differences when they differ. With --update, the baseline is written instead.
+func Check(cliContext *cli.Context) error {
+	if err := setup(cliContext); err != nil {
+		return err
+	}
+
+	ns := cliContext.String("namespace")
+
+	opts, err := diagramOptions(cliContext)
+	if err != nil {
+		return err
+	}
+
+	o, manifests, err := loadObjects(cliContext, cliContext.String("source"), ns)
+	if err != nil {
+		return err
+	}
+
+	current, err := graph(ns, o, opts)
+	if err != nil {
+		return err
+	}
+
+	if manifests {
+		current = current.Without(generatedKinds...)
+	}
+
+	current = current.WithoutAttributes(cliContext.StringSlice("ignore")...)
+	baselineFile := cliContext.String("baseline")
+
+	if cliContext.Bool("update") {
+		return saveBaseline(baselineFile, current)
+	}
+
+	f, err := os.Open(baselineFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("baseline %s not found, create it with --update: %w", baselineFile, err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("reading baseline: %w", err)
+	}
+	defer f.Close()
+
+	baseline, err := diagram.LoadGraph(f)
+	if err != nil {
+		return err
+	}
+
+	diff := diagram.CompareGraphs(baseline.WithoutAttributes(cliContext.StringSlice("ignore")...), current)
+	if !diff.Empty() {
+		return cli.Exit(diff.String(), driftExitCode)
+	}
+
+	log.Info().Msgf("Topology matches the baseline %s", baselineFile)
+
+	return nil
+}
+
+func saveBaseline(file string, g diagram.Graph) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("creating baseline: %w", err)
+	}
+	defer f.Close()
+
+	if err := diagram.SaveGraph(f, g); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Baseline %s updated with %d objects and %d links", file, len(g.Nodes), len(g.Edges))
+
+	return nil
+}
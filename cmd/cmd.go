@@ -3,11 +3,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 
+	"github.com/rs/zerolog/log"
 	"github.com/trois-six/k8s-diagrams/pkg/diagram"
 	"github.com/trois-six/k8s-diagrams/pkg/discovery"
 	"github.com/trois-six/k8s-diagrams/pkg/logger"
@@ -24,67 +25,223 @@ import (
 
 	// Blank import to allow client-go to connect on openstack.
 	_ "k8s.io/client-go/plugin/pkg/client/auth/openstack"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 // Run executes the command.
 func Run(cliContext *cli.Context) error {
-	logger.Setup()
+	if err := setup(cliContext); err != nil {
+		return err
+	}
+
+	ns := cliContext.String("namespace")
+
+	opts, err := diagramOptions(cliContext)
+	if err != nil {
+		return err
+	}
 
-	if err := setupEnvVars(cliContext); err != nil {
+	o, err := discover(cliContext, ns)
+	if err != nil {
 		return err
 	}
 
-	var kc string
+	drawnNs, drawn, err := redactObjects(cliContext, ns, o)
+	if err != nil {
+		return err
+	}
+
+	if !redacting(cliContext) {
+		opts = append(opts, diagram.WithCluster(clusterName(cliContext)))
+	}
+
+	d, err := newDiagram(cliContext, opts)
+	if err != nil {
+		return err
+	}
+
+	d.GenerateDiagram(drawnNs, drawn)
+
+	rename, err := aliases(cliContext)
+	if err != nil {
+		return err
+	}
+
+	url, window := cliContext.String("prometheus-url"), cliContext.String("prometheus-window")
+	if err = showTraffic(d, ns, url, window, rename); err != nil {
+		return err
+	}
+
+	if err = showCalls(d, ns, o, cliContext.StringSlice("flows"), rename); err != nil {
+		return err
+	}
+
+	return writeDiagram(cliContext, d)
+}
+
+func setup(cliContext *cli.Context) error {
+	logger.Setup()
+
+	return setupEnvVars(cliContext)
+}
+
+func kubeConfigPath(cliContext *cli.Context) (string, error) {
 	if cliContext.String("kubeconfig") != "" {
-		kc = cliContext.String("kubeconfig")
-	} else {
-		u, err := user.Current()
-		if err != nil {
-			return fmt.Errorf("don't know where is your kubeconfig: %w", err)
-		}
-		kc = filepath.Join(u.HomeDir, ".kube", "config")
+		return cliContext.String("kubeconfig"), nil
 	}
 
-	if _, err := os.Stat(kc); err != nil {
-		return fmt.Errorf("can't read kubeconfig: %w", err)
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("don't know where is your kubeconfig: %w", err)
 	}
 
-	ns := cliContext.String("namespace")
+	return filepath.Join(u.HomeDir, ".kube", "config"), nil
+}
+
+func kubeConfig(cliContext *cli.Context) (*rest.Config, error) {
+	kc, err := kubeConfigPath(cliContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(kc); err != nil {
+		return nil, fmt.Errorf("can't read kubeconfig: %w", err)
+	}
 
 	config, err := clientcmd.BuildConfigFromFlags("", kc)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
 	}
 
-	ctx := context.Background()
+	return config, nil
+}
+
+// clusterName returns the name of the cluster of the current context of the kubeconfig, or an empty name when it can't
+// be read.
+func clusterName(cliContext *cli.Context) string {
+	kc, err := kubeConfigPath(cliContext)
+	if err != nil {
+		return ""
+	}
 
-	k, err := discovery.NewDiscovery(ctx, config)
+	config, err := clientcmd.LoadFromFile(kc)
 	if err != nil {
-		log.Fatal(err)
+		log.Debug().Msgf("Reading the cluster name: %v", err)
+
+		return ""
+	}
+
+	if c, ok := config.Contexts[config.CurrentContext]; ok {
+		return c.Cluster
 	}
 
-	o, err := k.GenerateAll(ns)
+	return ""
+}
+
+// discover gets the objects of a namespace from the cluster.
+func discover(cliContext *cli.Context, ns string) (*discovery.Objects, error) {
+	config, err := kubeConfig(cliContext)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	d, err := diagram.NewDiagram(
-		cliContext.String("outputDirectory"),
-		cliContext.String("outputFilename"),
-		cliContext.String("label"),
+	source := discovery.FromCluster(
+		config,
+		discovery.WithRBAC(cliContext.Bool("rbac")),
+		discovery.WithHelmReleases(cliContext.Bool("helm") && cliContext.Bool("helm-secrets")),
+		discovery.WithClusterDomain(cliContext.String("cluster-domain")),
 	)
+
+	return source.Objects(context.Background(), ns)
+}
+
+func diagramOptions(cliContext *cli.Context) ([]diagram.Option, error) {
+	detail, err := detailLevel(cliContext.String("detail"))
+	if err != nil {
+		return nil, err
+	}
+
+	theme, err := diagram.Theme(cliContext.String("theme"))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	d.GenerateDiagram(ns, o)
+	c := loadedConfig(cliContext)
 
-	if err = d.RenderDiagram(); err != nil {
-		log.Fatal(err)
+	opts := []diagram.Option{
+		diagram.ShowScaledToZero(cliContext.Bool("show-scaled-to-zero")),
+		diagram.ShowOldReplicaSets(cliContext.Bool("show-old-replicasets")),
+		diagram.ShowRBAC(cliContext.Bool("rbac")),
+		diagram.ShowSecurity(cliContext.Bool("security")),
+		diagram.GroupByHelmRelease(cliContext.Bool("helm")),
+		diagram.GroupByLabels(groupLabels(cliContext.StringSlice("group-by"))...),
+		diagram.WithDetail(detail),
+		diagram.ShowLegend(cliContext.Bool("legend")),
+		diagram.WithClusterDomain(cliContext.String("cluster-domain")),
+		diagram.WithStyle(theme),
+		diagram.WithStyle(c.Style),
+		diagram.WithStyle(layoutStyle(cliContext)),
 	}
 
-	return nil
+	// Redacted objects are filtered before being redacted, see redactObjects.
+	if !redacting(cliContext) {
+		opts = append(opts, diagram.WithFilter(c.Include, c.Exclude))
+	}
+
+	return opts, nil
+}
+
+// layoutStyle returns the layout set by the flags, overriding the theme and the configuration file.
+func layoutStyle(cliContext *cli.Context) diagram.Style {
+	var s diagram.Style
+
+	if cliContext.IsSet("layered") {
+		s.Layered = diagram.Bool(cliContext.Bool("layered"))
+	}
+
+	if cliContext.IsSet("direction") {
+		s.Direction = strings.ToUpper(cliContext.String("direction"))
+	}
+
+	if cliContext.IsSet("splines") {
+		s.Splines = cliContext.String("splines")
+	}
+
+	if cliContext.IsSet("ranksep") {
+		s.RankSep = cliContext.Float64("ranksep")
+	}
+
+	if cliContext.IsSet("nodesep") {
+		s.NodeSep = cliContext.Float64("nodesep")
+	}
+
+	return s
+}
+
+func detailLevel(value string) (diagram.DetailLevel, error) {
+	for _, level := range diagram.DetailLevels() {
+		if string(level) == value {
+			return level, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown detail level %q", value)
+}
+
+// groupLabels expands the "app" shortcut of the group-by option into the recommended application labels.
+func groupLabels(values []string) []string {
+	var keys []string
+
+	for _, v := range values {
+		if v == "app" {
+			keys = append(keys, diagram.RecommendedGroupLabels()...)
+		} else {
+			keys = append(keys, v)
+		}
+	}
+
+	return keys
 }
 
 func setupEnvVars(context *cli.Context) error {
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/trois-six/k8s-diagrams/pkg/config"
+	"github.com/urfave/cli/v2"
+)
+
+// configKey is the key of the configuration in the metadata of the application.
+const configKey = "config"
+
+// LoadConfig reads the configuration file of the --config flag, or the default one when it exists, and sets the
+// global flags it gives values to, unless they are set on the command line or by environment variables.
+func LoadConfig(cliContext *cli.Context) error {
+	var (
+		c   *config.Config
+		err error
+	)
+
+	if path := cliContext.String("config"); path != "" {
+		c, err = config.Load(path)
+	} else {
+		c, err = config.LoadDefault()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	cliContext.App.Metadata[configKey] = c
+
+	return applyFlags(cliContext, c.Flags)
+}
+
+// ConfigureCommand sets the flags of the command the configuration gives values to, unless they are set on the
+// command line or by environment variables.
+func ConfigureCommand(cliContext *cli.Context) error {
+	return applyFlags(cliContext, loadedConfig(cliContext).Commands[cliContext.Command.Name])
+}
+
+// loadedConfig returns the configuration read by LoadConfig, or an empty one.
+func loadedConfig(cliContext *cli.Context) *config.Config {
+	if c, ok := cliContext.App.Metadata[configKey].(*config.Config); ok {
+		return c
+	}
+
+	return &config.Config{}
+}
+
+func applyFlags(cliContext *cli.Context, flags map[string]interface{}) error {
+	for name, value := range flags {
+		if cliContext.IsSet(name) {
+			continue
+		}
+
+		for _, v := range config.Values(value) {
+			if err := cliContext.Set(name, v); err != nil {
+				return fmt.Errorf("setting flag %s from configuration: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	"github.com/urfave/cli/v2"
+)
+
+// liveSource is the source of objects read from the cluster.
+const liveSource = "live"
+
+// generatedKinds are the objects created by controllers, that manifests don't declare.
+var generatedKinds = []string{"Pod", "ReplicaSet"}
+
+// Snapshot saves the objects of a namespace to a file, to be compared later.
+func Snapshot(cliContext *cli.Context) error {
+	if err := setup(cliContext); err != nil {
+		return err
+	}
+
+	o, err := discover(cliContext, cliContext.String("namespace"))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(cliContext.String("file"))
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+	defer f.Close()
+
+	return discovery.SaveSnapshot(f, o)
+}
+
+// Diff draws the objects of two sources in one diagram, highlighting the objects and links added, removed and changed
+// from the first source to the second one.
+func Diff(cliContext *cli.Context) error {
+	if err := setup(cliContext); err != nil {
+		return err
+	}
+
+	ns := cliContext.String("namespace")
+
+	opts, err := diagramOptions(cliContext)
+	if err != nil {
+		return err
+	}
+
+	older, olderManifests, err := loadObjects(cliContext, cliContext.String("from"), ns)
+	if err != nil {
+		return err
+	}
+
+	newer, newerManifests, err := loadObjects(cliContext, cliContext.String("to"), ns)
+	if err != nil {
+		return err
+	}
+
+	if _, older, err = redactObjects(cliContext, ns, older); err != nil {
+		return err
+	}
+
+	if ns, newer, err = redactObjects(cliContext, ns, newer); err != nil {
+		return err
+	}
+
+	olderGraph, err := graph(ns, older, opts)
+	if err != nil {
+		return err
+	}
+
+	newerGraph, err := graph(ns, newer, opts)
+	if err != nil {
+		return err
+	}
+
+	if olderManifests || newerManifests {
+		olderGraph, newerGraph = olderGraph.Without(generatedKinds...), newerGraph.Without(generatedKinds...)
+	}
+
+	diff := diagram.CompareGraphs(olderGraph, newerGraph)
+
+	log.Info().Msgf("%d objects and %d links differ", len(diff.Nodes), len(diff.Edges))
+
+	merged, err := discovery.Merge(newer, older)
+	if err != nil {
+		return fmt.Errorf("merging objects: %w", err)
+	}
+
+	d, err := newDiagram(cliContext, opts)
+	if err != nil {
+		return err
+	}
+
+	d.GenerateDiagram(ns, merged)
+	d.Highlight(diff)
+
+	return writeDiagram(cliContext, d)
+}
+
+// loadObjects reads the objects of a source: the live cluster, a snapshot file, or YAML manifests. It tells if the
+// objects were read from manifests.
+func loadObjects(cliContext *cli.Context, source, ns string) (*discovery.Objects, bool, error) {
+	if source == liveSource {
+		o, err := discover(cliContext, ns)
+
+		return o, false, err
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading source: %w", err)
+	}
+
+	if ext := strings.ToLower(filepath.Ext(source)); info.IsDir() || ext == ".yaml" || ext == ".yml" {
+		o, err := discovery.LoadManifests(ns, source)
+
+		return o, true, err
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading snapshot: %w", err)
+	}
+	defer f.Close()
+
+	o, err := discovery.LoadSnapshot(f)
+
+	return o, false, err
+}
+
+// graph returns the topology of the diagram of the objects, without drawing it.
+func graph(ns string, o *discovery.Objects, opts []diagram.Option) (diagram.Graph, error) {
+	d, err := diagram.NewDiagram("", "", "", opts...)
+	if err != nil {
+		return diagram.Graph{}, err
+	}
+
+	d.GenerateDiagram(ns, o)
+
+	return d.Graph(), nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+// Exit codes of the commands, telling automation why they failed. The check and lint commands exit with code 3 when
+// they find differences or problems.
+const (
+	exitFailure           = 1
+	exitUnauthorized      = 4
+	exitForbidden         = 5
+	exitNamespaceNotFound = 6
+	exitRenderFailure     = 7
+)
+
+// ExitCode returns the exit code matching the error a command failed with: 4 when the cluster rejects the
+// credentials, 5 when they lack a permission, 6 when the namespace doesn't exist, 7 when the diagram can't be
+// rendered, and 1 otherwise.
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, discovery.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, discovery.ErrForbidden):
+		return exitForbidden
+	case errors.Is(err, discovery.ErrNamespaceNotFound):
+		return exitNamespaceNotFound
+	case errors.Is(err, diagram.ErrRender):
+		return exitRenderFailure
+	default:
+		return exitFailure
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	"github.com/trois-six/k8s-diagrams/pkg/lint"
+	"github.com/urfave/cli/v2"
+)
+
+// lintExitCode is the exit code of the lint command when findings reach the --fail-on severity.
+const lintExitCode = 3
+
+// failOnNone disables the failure of the lint command.
+const failOnNone = "none"
+
+var lintWriters = map[string]func(io.Writer, []lint.Finding) error{
+	"text":  lint.WriteText,
+	"json":  lint.WriteJSON,
+	"sarif": lint.WriteSARIF,
+}
+
+// Lint checks the objects of a source, the live cluster by default, for topology problems, and writes the findings to
+// the standard output. It fails when findings reach the --fail-on severity. With --highlight, the diagram is drawn
+// with the objects having problems in red or amber, and the findings are written to the standard error when the
+// diagram is streamed to the standard output.
+func Lint(cliContext *cli.Context) error {
+	if err := setup(cliContext); err != nil {
+		return err
+	}
+
+	write, ok := lintWriters[cliContext.String("format")]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected text, json or sarif", cliContext.String("format"))
+	}
+
+	failOn := lint.Severity(cliContext.String("fail-on"))
+	if !validFailOn(failOn) {
+		return fmt.Errorf("unknown severity %q, expected error, warning, note or none", failOn)
+	}
+
+	ns := cliContext.String("namespace")
+
+	o, manifests, err := loadObjects(cliContext, cliContext.String("source"), ns)
+	if err != nil {
+		return err
+	}
+
+	run := lint.Run
+	if manifests {
+		run = lint.RunStatic
+	}
+
+	// The findings name the objects by their aliases with --redact, as the highlighted diagram does.
+	lintNs, linted, err := redactAll(cliContext, ns, o)
+	if err != nil {
+		return err
+	}
+
+	findings := run(lintNs, linted)
+
+	if err := write(findingsOutput(cliContext), findings); err != nil {
+		return err
+	}
+
+	if cliContext.Bool("highlight") {
+		if err := highlightFindings(cliContext, ns, o, findings); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range findings {
+		if failOn != failOnNone && f.Severity.AtLeast(failOn) {
+			return cli.Exit(lint.Summary(findings), lintExitCode)
+		}
+	}
+
+	return nil
+}
+
+// findingsOutput is where the findings are written: the standard output, or the standard error when the highlighted
+// diagram is streamed to the standard output, for both to be read apart.
+func findingsOutput(cliContext *cli.Context) io.Writer {
+	if cliContext.Bool("highlight") && cliContext.String("outputFilename") == stdout {
+		return os.Stderr
+	}
+
+	return os.Stdout
+}
+
+func validFailOn(s lint.Severity) bool {
+	if s == failOnNone {
+		return true
+	}
+
+	for _, severity := range lint.Severities() {
+		if s == severity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// highlightFindings draws the diagram of the objects, flagging the objects having problems. The objects are redacted
+// with --redact, as the findings are.
+func highlightFindings(cliContext *cli.Context, ns string, o *discovery.Objects, findings []lint.Finding) error {
+	opts, err := diagramOptions(cliContext)
+	if err != nil {
+		return err
+	}
+
+	ns, o, err = redactObjects(cliContext, ns, o)
+	if err != nil {
+		return err
+	}
+
+	d, err := newDiagram(cliContext, opts)
+	if err != nil {
+		return err
+	}
+
+	d.GenerateDiagram(ns, o)
+
+	flags := make([]diagram.Flag, 0, len(findings))
+	for _, f := range findings {
+		flags = append(flags, diagram.Flag{
+			Key:      diagram.NodeKey(f.Kind, f.Namespace, f.Name),
+			Message:  f.Rule,
+			Critical: f.Severity == lint.SeverityError,
+		})
+	}
+
+	for _, f := range d.Flag(flags) {
+		log.Debug().Msgf("Object not drawn, not flagged: %s", f.Key)
+	}
+
+	return writeDiagram(cliContext, d)
+}
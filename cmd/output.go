@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/urfave/cli/v2"
+)
+
+// stdout is the output filename streaming the DOT source of the diagram to the standard output.
+const stdout = "-"
+
+// outputFormat tells the format of the output file, when it is the standard output or a path with a known extension.
+func outputFormat(output string) (diagram.Format, bool) {
+	if output == stdout {
+		return diagram.FormatDOT, true
+	}
+
+	return diagram.FormatFromPath(output)
+}
+
+// newDiagram creates the diagram of the output flags: rendered to the output directory, or in a temporary directory
+// when written to the standard output or to a path.
+func newDiagram(cliContext *cli.Context, opts []diagram.Option) (*diagram.Diagram, error) {
+	output := cliContext.String("outputFilename")
+
+	if _, ok := outputFormat(output); ok {
+		return diagram.NewTempDiagram("k8s", cliContext.String("label"), opts...)
+	}
+
+	return diagram.NewDiagram(cliContext.String("outputDirectory"), output, cliContext.String("label"), opts...)
+}
+
+// writeDiagram renders the diagram to the output directory, or writes it to the standard output or to a path in the
+// format of its extension.
+func writeDiagram(cliContext *cli.Context, d *diagram.Diagram) error {
+	output := cliContext.String("outputFilename")
+
+	format, ok := outputFormat(output)
+	if !ok {
+		return d.RenderDiagram()
+	}
+
+	defer d.Close()
+
+	// The icons of the DOT source streamed to the standard output are cached, and referenced by absolute paths for it
+	// to render from any directory of this machine.
+	if output == stdout {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("locating icons directory: %w", err)
+		}
+
+		return d.WriteDOTWithIcons(os.Stdout, filepath.Join(cache, "k8s-diagrams"))
+	}
+
+	return writeFile(d, output, format)
+}
+
+// writeFile writes the diagram to a path, along with the icons its DOT source references.
+func writeFile(d *diagram.Diagram, path string, format diagram.Format) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	if format == diagram.FormatDOT {
+		if err := d.SaveIcons(dir); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+
+	if err := d.Write(f, format); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+
+	return nil
+}
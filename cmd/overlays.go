@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	"github.com/trois-six/k8s-diagrams/pkg/flows"
+	"github.com/trois-six/k8s-diagrams/pkg/metrics"
+)
+
+// showTraffic labels the links of the diagram with the traffic read from a Prometheus compatible API, when its URL is
+// set. The traffic of the namespace is read by its real name, and its links renamed to match the drawn objects.
+func showTraffic(d *diagram.Diagram, ns, url, window string, rename func(string) string) error {
+	if url == "" {
+		return nil
+	}
+
+	t, err := metrics.NewClient(url, metrics.WithWindow(window)).Traffic(context.Background(), ns)
+	if err != nil {
+		return fmt.Errorf("reading traffic: %w", err)
+	}
+
+	d.ShowTraffic(t.Rename(rename))
+
+	return nil
+}
+
+// showCalls draws the calls observed in flow files between the pods and services of the diagram. The flows are
+// resolved against the discovered objects, before they are redacted, and the calls renamed to match the drawn objects.
+func showCalls(d *diagram.Diagram, ns string, o *discovery.Objects, files []string, rename func(string) string) error {
+	var records []flows.Record
+
+	for _, file := range files {
+		r, err := flows.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		records = append(records, r...)
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	calls, unresolved := flows.Resolve(ns, o, records)
+	if unresolved > 0 {
+		log.Info().Msgf("%d of %d flows don't link two objects of the namespace", unresolved, len(records))
+	}
+
+	if missing := d.ShowCalls(flows.Rename(calls, rename)); missing > 0 {
+		log.Debug().Msgf("%d calls link objects not drawn", missing)
+	}
+
+	return nil
+}
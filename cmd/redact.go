@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// redactorKey is the key of the redactor in the metadata of the application.
+	redactorKey = "redactor"
+	// mappingMode keeps the mapping file private, as it reveals the redacted names.
+	mappingMode = 0o600
+)
+
+// redacting tells if the --redact flag is set.
+func redacting(cliContext *cli.Context) bool {
+	return cliContext.String("redact") != ""
+}
+
+// redactObjects replaces the names, hosts and IP addresses of the objects with aliases when --redact is set, and
+// returns them along with the alias of the namespace. The objects are filtered by the configuration file first, as
+// its rules name the objects by their real names. The objects of a command share their aliases, which are written to
+// the --redact-mapping file.
+func redactObjects(cliContext *cli.Context, ns string, o *discovery.Objects) (string, *discovery.Objects, error) {
+	if !redacting(cliContext) {
+		return ns, o, nil
+	}
+
+	if c := loadedConfig(cliContext); len(c.Include) > 0 || len(c.Exclude) > 0 {
+		o = o.Filter(c.Include, c.Exclude)
+	}
+
+	return redactAll(cliContext, ns, o)
+}
+
+// redactAll redacts the objects as redactObjects does, without filtering them, such as to lint them all.
+func redactAll(cliContext *cli.Context, ns string, o *discovery.Objects) (string, *discovery.Objects, error) {
+	if !redacting(cliContext) {
+		return ns, o, nil
+	}
+
+	r, err := redactor(cliContext)
+	if err != nil {
+		return "", nil, err
+	}
+
+	o, err = r.Redact(o)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if path := cliContext.String("redact-mapping"); path != "" {
+		if err := saveMapping(path, r.Aliases()); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return r.Alias(ns), o, nil
+}
+
+// aliases returns the function renaming the objects as redactObjects does, so that the overlays matched by real names
+// are drawn on redacted diagrams, or keeping their names when --redact isn't set.
+func aliases(cliContext *cli.Context) (func(string) string, error) {
+	if !redacting(cliContext) {
+		return func(name string) string { return name }, nil
+	}
+
+	r, err := redactor(cliContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Alias, nil
+}
+
+// redactor returns the redactor of the command, created on first use.
+func redactor(cliContext *cli.Context) (*discovery.Redactor, error) {
+	if r, ok := cliContext.App.Metadata[redactorKey].(*discovery.Redactor); ok {
+		return r, nil
+	}
+
+	r, err := discovery.NewRedactor(
+		discovery.RedactMode(cliContext.String("redact")),
+		cliContext.String("redact-salt"),
+		cliContext.String("cluster-domain"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cliContext.App.Metadata[redactorKey] = r
+
+	return r, nil
+}
+
+func saveMapping(path string, aliases map[string]string) error {
+	content, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding redaction mapping: %w", err)
+	}
+
+	if err := ioutil.WriteFile(path, append(content, '\n'), mappingMode); err != nil {
+		return fmt.Errorf("writing redaction mapping: %w", err)
+	}
+
+	return nil
+}
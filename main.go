@@ -13,6 +13,11 @@ func main() {
 		Name:  "k8s-diagrams",
 		Usage: "Create diagram from the Kubernetes API.",
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "The configuration file. (default: ~/.config/k8s-diagrams/config.yaml when it exists)",
+				EnvVars: []string{"K8S_DIAGRAMS_CONFIG"},
+			},
 			&cli.StringFlag{
 				Name:    "namespace",
 				Aliases: []string{"n"},
@@ -26,11 +31,18 @@ func main() {
 				Usage:   "The path to your kube config file.",
 				EnvVars: []string{"KUBECONFIG"},
 			},
+			&cli.StringFlag{
+				Name:  "cluster-domain",
+				Usage: "The domain the services of the cluster are named in, to link ExternalName services to them.",
+				Value: "cluster.local",
+			},
 			&cli.StringFlag{
 				Name:    "outputFilename",
 				Aliases: []string{"o"},
-				Usage:   "The output filename.",
-				Value:   "k8s",
+				Usage: "The output filename, - for the DOT source on stdout, its icons copied to the user cache " +
+					"directory and referenced by absolute paths, or a path whose extension is the format: " +
+					"dot, svg, png, pdf, jpg, gif, json or mmd.",
+				Value: "k8s",
 			},
 			&cli.StringFlag{
 				Name:    "outputDirectory",
@@ -44,11 +56,201 @@ func main() {
 				Usage:   "The diagram label.",
 				Value:   "Kubernetes",
 			},
+			&cli.BoolFlag{
+				Name:  "show-scaled-to-zero",
+				Usage: "Draw the workloads scaled to zero.",
+			},
+			&cli.BoolFlag{
+				Name:  "show-old-replicasets",
+				Usage: "Draw the replicaSets left behind by deployment rollouts.",
+			},
+			&cli.BoolFlag{
+				Name:  "rbac",
+				Usage: "Draw the service accounts of the pods, and the roles bound to them.",
+			},
+			&cli.BoolFlag{
+				Name:  "security",
+				Usage: "Outline the pods with risky settings and the services exposed to the Internet in red.",
+			},
+			&cli.BoolFlag{
+				Name:  "helm",
+				Usage: "Group the objects by Helm release.",
+			},
+			&cli.BoolFlag{
+				Name:  "helm-secrets",
+				Usage: "With --helm, read the chart and revision of the releases from the Helm secrets.",
+			},
+			&cli.StringSliceFlag{
+				Name:  "group-by",
+				Usage: "Nest the objects in groups by label keys, or \"app\" for the part-of, name and component labels.",
+			},
+			&cli.StringFlag{
+				Name:    "prometheus-url",
+				Usage:   "The URL of a Prometheus compatible API, to label the links with the traffic flowing through them.",
+				EnvVars: []string{"PROMETHEUS_URL"},
+			},
+			&cli.StringFlag{
+				Name:  "prometheus-window",
+				Usage: "The range the request and error rates are computed over.",
+				Value: "5m",
+			},
+			&cli.StringSliceFlag{
+				Name:  "flows",
+				Usage: "Flow log files, Hubble JSON exports or src,dst,port[,count] CSV files, to draw the observed calls.",
+			},
+			&cli.StringFlag{
+				Name:  "theme",
+				Usage: "The theme of the diagram: default, dark for slides, or monochrome for printing.",
+				Value: "default",
+			},
+			&cli.StringFlag{
+				Name:  "direction",
+				Usage: "The direction of the diagram: TB, LR, BT or RL.",
+				Value: "TB",
+			},
+			&cli.StringFlag{
+				Name:  "splines",
+				Usage: "How the edges are routed: curved, ortho, polyline, spline or line.",
+				Value: "curved",
+			},
+			&cli.Float64Flag{
+				Name:  "ranksep",
+				Usage: "The space between the ranks of the diagram, in inches.",
+				Value: 0.75,
+			},
+			&cli.Float64Flag{
+				Name:  "nodesep",
+				Usage: "The space between the nodes of a rank, in inches.",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "layered",
+				Usage: "Pin the Internet, ingresses, services, workloads and pods onto consecutive ranks.",
+			},
+			&cli.BoolFlag{
+				Name:  "legend",
+				Usage: "Draw a legend of the icons, colors and edge styles, " +
+					"and a footer telling where and when the diagram was drawn.",
+			},
+			&cli.StringFlag{
+				Name:  "detail",
+				Usage: "How much of the pods is drawn: pods, or containers to list their containers.",
+				Value: "pods",
+			},
+			&cli.StringFlag{
+				Name: "redact",
+				Usage: "Replace the names, hosts and IP addresses of the diagram with aliases, to share it: " +
+					"hash for hashed aliases, or sequential for numbered ones.",
+			},
+			&cli.StringFlag{
+				Name:    "redact-salt",
+				Usage:   "The secret hashed with the names by --redact hash, for their aliases not to be guessed.",
+				EnvVars: []string{"K8S_DIAGRAMS_REDACT_SALT"},
+			},
+			&cli.StringFlag{
+				Name:  "redact-mapping",
+				Usage: "A private file to write the names redacted by --redact to, by alias, as JSON.",
+			},
 		},
+		Before: cmd.LoadConfig,
 		Action: cmd.Run,
+		Commands: []*cli.Command{
+			{
+				Name:   "snapshot",
+				Usage:  "Save the objects of the namespace to a file, to compare them later.",
+				Before: cmd.ConfigureCommand,
+				Action: cmd.Snapshot,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "file",
+						Aliases: []string{"f"},
+						Usage:   "The snapshot file.",
+						Value:   "snapshot.json",
+					},
+				},
+			},
+			{
+				Name: "check",
+				Usage: "Compare the topology of the namespace with a baseline file, and exit with code 3 and a report " +
+					"of the differences when they differ.",
+				Before: cmd.ConfigureCommand,
+				Action: cmd.Check,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "baseline",
+						Aliases: []string{"b"},
+						Usage:   "The baseline file.",
+						Value:   "topology.json",
+					},
+					&cli.BoolFlag{
+						Name:  "update",
+						Usage: "Write the current topology to the baseline file instead of comparing them.",
+					},
+					&cli.StringFlag{
+						Name:  "source",
+						Usage: "The source to check: live, a snapshot file, or a YAML manifest file or directory.",
+						Value: "live",
+					},
+					&cli.StringSliceFlag{
+						Name:  "ignore",
+						Usage: "Attributes of the objects not to compare: images, replicas, ports or type.",
+					},
+				},
+			},
+			{
+				Name: "lint",
+				Usage: "Check the objects of the namespace for topology problems, and exit with code 3 when problems " +
+					"reach the --fail-on severity.",
+				Before: cmd.ConfigureCommand,
+				Action: cmd.Lint,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "The output format: text, json or sarif.",
+						Value: "text",
+					},
+					&cli.StringFlag{
+						Name:  "source",
+						Usage: "The source to check: live, a snapshot file, or a YAML manifest file or directory.",
+						Value: "live",
+					},
+					&cli.StringFlag{
+						Name:  "fail-on",
+						Usage: "The lowest severity failing the command: error, warning, note or none.",
+						Value: "error",
+					},
+					&cli.BoolFlag{
+						Name:  "highlight",
+						Usage: "Draw the diagram, with the objects having problems in red or amber.",
+					},
+				},
+			},
+			{
+				Name: "diff",
+				Usage: "Draw the objects of two sources in one diagram, added ones in green, removed ones in red and " +
+					"changed ones in amber.",
+				Before: cmd.ConfigureCommand,
+				Action: cmd.Diff,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "from",
+						Usage:    "The source to compare from: live, a snapshot file, or a YAML manifest file or directory.",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "to",
+						Usage: "The source to compare to: live, a snapshot file, or a YAML manifest file or directory.",
+						Value: "live",
+					},
+				},
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
-		log.Fatal().Err(err).Msg("Error while executing command")
+		code := cmd.ExitCode(err)
+
+		log.Error().Err(err).Int("exitCode", code).Msg("Error while executing command")
+		os.Exit(code)
 	}
 }
@@ -0,0 +1,84 @@
+// Package config reads the configuration files of k8s-diagrams.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the content of a configuration file: the default values of the flags, the style of the diagrams and the
+// rules filtering the objects they draw.
+type Config struct {
+	// Flags are the values of the global flags, by name, such as namespace or group-by.
+	Flags map[string]interface{} `json:"flags,omitempty"`
+	// Commands are the values of the flags of the commands, by command and flag name, such as check and baseline.
+	Commands map[string]map[string]interface{} `json:"commands,omitempty"`
+	Style    diagram.Style                     `json:"style,omitempty"`
+	Include  []discovery.Rule                  `json:"include,omitempty"`
+	Exclude  []discovery.Rule                  `json:"exclude,omitempty"`
+}
+
+// DefaultPath returns the path of the configuration file read when none is given: ~/.config/k8s-diagrams/config.yaml.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locating configuration: %w", err)
+	}
+
+	return filepath.Join(home, ".config", "k8s-diagrams", "config.yaml"), nil
+}
+
+// Load reads a configuration file. Unknown fields are rejected, to catch their typos.
+func Load(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading configuration: %w", err)
+	}
+
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(content, c); err != nil {
+		return nil, fmt.Errorf("parsing configuration %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// LoadDefault reads the configuration file of the default path, and returns an empty configuration when it doesn't
+// exist.
+func LoadDefault() (*Config, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+
+	return c, err
+}
+
+// Values returns the values of a flag as the strings the command line would give, one per element of a list.
+func Values(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	c, err := Load(writeConfig(t, `
+flags:
+  namespace: shop
+  rbac: true
+  group-by: [team, app]
+commands:
+  check:
+    baseline: shop.json
+style:
+  direction: LR
+  kinds:
+    Service:
+      color: "#FFFFFF"
+exclude:
+  - kinds: [Pod]
+    names: ["*-canary-*"]
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := Values(c.Flags["group-by"]); !reflect.DeepEqual(got, []string{"team", "app"}) {
+		t.Errorf("group-by = %v, want [team app]", got)
+	}
+
+	if got := Values(c.Flags["rbac"]); !reflect.DeepEqual(got, []string{"true"}) {
+		t.Errorf("rbac = %v, want [true]", got)
+	}
+
+	if got := Values(c.Commands["check"]["baseline"]); !reflect.DeepEqual(got, []string{"shop.json"}) {
+		t.Errorf("check baseline = %v, want [shop.json]", got)
+	}
+
+	wantStyle := diagram.Style{Direction: "LR", Kinds: map[string]diagram.NodeStyle{"Service": {Color: "#FFFFFF"}}}
+	if !reflect.DeepEqual(c.Style, wantStyle) {
+		t.Errorf("style = %+v, want %+v", c.Style, wantStyle)
+	}
+
+	wantExclude := []discovery.Rule{{Kinds: []string{"Pod"}, Names: []string{"*-canary-*"}}}
+	if !reflect.DeepEqual(c.Exclude, wantExclude) {
+		t.Errorf("exclude = %+v, want %+v", c.Exclude, wantExclude)
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	if _, err := Load(writeConfig(t, "stlye:\n  direction: LR\n")); err == nil {
+		t.Error("Load() succeeded with an unknown field")
+	}
+}
@@ -0,0 +1,80 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/flows"
+)
+
+const callColor = "#2874A6"
+
+// callEdge styles the edges of the calls observed in flow logs, apart from the declared links.
+func callEdge(o *diagram.EdgeOptions) {
+	o.Style = "bold"
+	o.Color = callColor
+	o.Font.Color = callColor
+}
+
+// ShowCalls draws the calls observed between the pods and services of the diagram, labeled with their count per port.
+// It returns the number of calls whose ends aren't drawn.
+func (d *Diagram) ShowCalls(calls []flows.Call) int {
+	type pair struct {
+		from, to *diagram.Node
+	}
+
+	var (
+		pairs   []pair
+		missing int
+	)
+
+	labels := make(map[pair][]string)
+
+	for _, c := range calls {
+		from, to := d.callNode(c.From), d.callNode(c.To)
+		if from == nil || to == nil {
+			missing++
+
+			continue
+		}
+
+		p := pair{from: from, to: to}
+		if _, ok := labels[p]; !ok {
+			pairs = append(pairs, p)
+		}
+
+		labels[p] = append(labels[p], callLabel(c))
+	}
+
+	for _, p := range pairs {
+		d.diag.ConnectByID(p.from.ID(), p.to.ID(), callEdge, edgeLabel(strings.Join(labels[p], "\\n")))
+	}
+
+	return missing
+}
+
+func (d *Diagram) callNode(o flows.Object) *diagram.Node {
+	switch o.Kind {
+	case flows.KindPod:
+		return d.pods[o.Name]
+	case flows.KindService:
+		return d.services[o.Name]
+	default:
+		return nil
+	}
+}
+
+// callLabel describes a call, as in 42 calls → 8080.
+func callLabel(c flows.Call) string {
+	label := fmt.Sprintf("%d calls", c.Count)
+	if c.Count == 1 {
+		label = "1 call"
+	}
+
+	if c.Port != 0 {
+		label += fmt.Sprintf(" → %d", c.Port)
+	}
+
+	return label
+}
@@ -0,0 +1,86 @@
+package diagram
+
+import (
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/flows"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShowCalls(t *testing.T) {
+	d := generateFromFakeCluster(t, webService(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: testNamespace},
+	})
+
+	worker := flows.Object{Kind: flows.KindPod, Name: "worker"}
+	web := flows.Object{Kind: flows.KindService, Name: "web"}
+
+	missing := d.ShowCalls([]flows.Call{
+		{From: worker, To: web, Port: 80, Count: 42},
+		{From: worker, To: web, Port: 443, Count: 1},
+		{From: worker, To: flows.Object{Kind: flows.KindService, Name: "gone"}, Port: 80, Count: 3},
+	})
+
+	if missing != 1 {
+		t.Errorf("got %d missing calls, want 1", missing)
+	}
+
+	edges := 0
+
+	for _, e := range d.diag.Edges() {
+		if e.Start() != d.pods["worker"].ID() || e.End() != d.services["web"].ID() {
+			continue
+		}
+
+		edges++
+
+		if want := "42 calls → 80\\n1 call → 443"; e.Options.Label != want {
+			t.Errorf("got label %q, want %q", e.Options.Label, want)
+		}
+	}
+
+	if edges != 1 {
+		t.Errorf("got %d call edges, want 1", edges)
+	}
+}
+
+func TestShowCallsRedacted(t *testing.T) {
+	o := discoverFakeCluster(t, webService(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: testNamespace},
+	})
+
+	d, r := generateRedacted(t, o)
+
+	// The flows name the objects by their real names, resolved against the objects before they are redacted.
+	calls, unresolved := flows.Resolve(testNamespace, o, []flows.Record{{
+		Source:      flows.Endpoint{Namespace: testNamespace, Pod: "worker"},
+		Destination: flows.Endpoint{Namespace: testNamespace, Service: "web"},
+		Port:        80,
+		Count:       3,
+	}})
+	if unresolved != 0 {
+		t.Fatalf("got %d unresolved flows, want 0", unresolved)
+	}
+
+	if missing := d.ShowCalls(flows.Rename(calls, r.Alias)); missing != 0 {
+		t.Errorf("got %d missing calls, want 0", missing)
+	}
+
+	worker, web := d.pods[r.Alias("worker")], d.services[r.Alias("web")]
+	if worker == nil || web == nil {
+		t.Fatalf("redacted objects not found in %v and %v", d.pods, d.services)
+	}
+
+	edges := 0
+
+	for _, e := range d.diag.Edges() {
+		if e.Start() == worker.ID() && e.End() == web.ID() && e.Options.Label == "3 calls → 80" {
+			edges++
+		}
+	}
+
+	if edges != 1 {
+		t.Errorf("got %d call edges between the redacted objects, want 1", edges)
+	}
+}
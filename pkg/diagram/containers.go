@@ -0,0 +1,252 @@
+package diagram
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DetailLevel tells how much of the pods content is drawn.
+type DetailLevel string
+
+const (
+	// DetailPods draws pods as opaque nodes.
+	DetailPods DetailLevel = "pods"
+	// DetailContainers lists the containers of the pods in their label, and their image, resources and probes in
+	// their tooltip.
+	DetailContainers DetailLevel = "containers"
+)
+
+const (
+	istioStatusAnnotation    = "sidecar.istio.io/status"
+	linkerdVersionAnnotation = "linkerd.io/proxy-version"
+	linkerdProxyContainer    = "linkerd-proxy"
+)
+
+// DetailLevels lists the supported detail levels.
+func DetailLevels() []DetailLevel {
+	return []DetailLevel{DetailPods, DetailContainers}
+}
+
+// container is a container of a pod, along with the way it was added to the pod.
+type container struct {
+	kind      string
+	name      string
+	image     string
+	resources corev1.ResourceRequirements
+	probes    []string
+}
+
+// podContainers lists the init, regular, sidecar and ephemeral containers of a pod.
+func podContainers(v corev1.Pod) []container {
+	sidecars := injectedSidecars(v)
+	for name := range nativeSidecars(v) {
+		sidecars[name] = true
+	}
+
+	var containers []container
+
+	for _, c := range v.Spec.InitContainers {
+		containers = append(containers, newContainer("init", c, sidecars))
+	}
+
+	for _, c := range v.Spec.Containers {
+		containers = append(containers, newContainer("", c, sidecars))
+	}
+
+	for _, c := range v.Spec.EphemeralContainers {
+		containers = append(containers, container{
+			kind:  "ephemeral",
+			name:  c.Name,
+			image: c.Image,
+		})
+	}
+
+	return containers
+}
+
+func newContainer(kind string, c corev1.Container, sidecars map[string]bool) container {
+	if sidecars[c.Name] {
+		kind = strings.TrimSpace(kind + " sidecar")
+	}
+
+	return container{
+		kind:      kind,
+		name:      c.Name,
+		image:     c.Image,
+		resources: c.Resources,
+		probes:    probes(c),
+	}
+}
+
+// injectedSidecars lists the containers injected in a pod by the Istio and Linkerd service meshes.
+func injectedSidecars(v corev1.Pod) map[string]bool {
+	sidecars := make(map[string]bool)
+
+	if raw, ok := v.Annotations[istioStatusAnnotation]; ok {
+		var status struct {
+			InitContainers []string `json:"initContainers"`
+			Containers     []string `json:"containers"`
+		}
+
+		if err := json.Unmarshal([]byte(raw), &status); err == nil {
+			for _, name := range append(status.InitContainers, status.Containers...) {
+				sidecars[name] = true
+			}
+		}
+	}
+
+	if _, ok := v.Annotations[linkerdVersionAnnotation]; ok {
+		sidecars[linkerdProxyContainer] = true
+	}
+
+	return sidecars
+}
+
+// nativeSidecars lists the init containers of a pod with an Always restart policy, which keep running along with the
+// containers started after them. The Kubernetes API client doesn't read the restart policy of containers yet, so they
+// are told from their status instead: a regular init container has completed before the next container starts. The
+// native sidecars of pods not started yet aren't told apart.
+func nativeSidecars(v corev1.Pod) map[string]bool {
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, list := range [][]corev1.ContainerStatus{v.Status.InitContainerStatuses, v.Status.ContainerStatuses} {
+		for _, s := range list {
+			statuses[s.Name] = s
+		}
+	}
+
+	names := make([]string, 0, len(v.Spec.InitContainers)+len(v.Spec.Containers))
+	for _, c := range append(append([]corev1.Container{}, v.Spec.InitContainers...), v.Spec.Containers...) {
+		names = append(names, c.Name)
+	}
+
+	sidecars := make(map[string]bool)
+
+	for i, c := range v.Spec.InitContainers {
+		if statuses[c.Name].State.Running == nil {
+			continue
+		}
+
+		for _, next := range names[i+1:] {
+			if state := statuses[next].State; state.Running != nil || state.Terminated != nil {
+				sidecars[c.Name] = true
+
+				break
+			}
+		}
+	}
+
+	return sidecars
+}
+
+func probes(c corev1.Container) []string {
+	var probes []string
+
+	for name, p := range map[string]*corev1.Probe{
+		"liveness":  c.LivenessProbe,
+		"readiness": c.ReadinessProbe,
+		"startup":   c.StartupProbe,
+	} {
+		if p == nil {
+			continue
+		}
+
+		switch {
+		case p.HTTPGet != nil:
+			probes = append(probes, fmt.Sprintf("%s http :%s%s", name, p.HTTPGet.Port.String(), p.HTTPGet.Path))
+		case p.TCPSocket != nil:
+			probes = append(probes, fmt.Sprintf("%s tcp :%s", name, p.TCPSocket.Port.String()))
+		case p.Exec != nil:
+			probes = append(probes, name+" exec")
+		}
+	}
+
+	sort.Strings(probes)
+
+	return probes
+}
+
+// shortImage strips the registry and repository path of an image, keeping its name and tag or digest.
+func shortImage(image string) string {
+	return image[strings.LastIndex(image, "/")+1:]
+}
+
+// badge describes a container as kind name: image.
+func (c container) badge() string {
+	label := c.name + ": " + shortImage(c.image)
+	if c.kind != "" {
+		label = c.kind + " " + label
+	}
+
+	return label
+}
+
+// tooltip describes the image, resources and probes of a container.
+func (c container) tooltip() string {
+	lines := []string{c.badge(), "  image: " + c.image}
+
+	if r := resources(c.resources); r != "" {
+		lines = append(lines, "  "+r)
+	}
+
+	for _, p := range c.probes {
+		lines = append(lines, "  "+p)
+	}
+
+	return strings.Join(lines, "\\n")
+}
+
+func resources(r corev1.ResourceRequirements) string {
+	var parts []string
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, hasRequest := r.Requests[name]
+		limit, hasLimit := r.Limits[name]
+
+		if !hasRequest && !hasLimit {
+			continue
+		}
+
+		part := string(name) + " "
+
+		if hasRequest {
+			part += request.String()
+		} else {
+			part += "-"
+		}
+
+		if hasLimit {
+			part += "/" + limit.String()
+		} else {
+			part += "/-"
+		}
+
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return "requests/limits: " + strings.Join(parts, ", ")
+}
+
+// GenerateContainers lists the containers of a pod in its label, and describes them in its tooltip.
+func (d *Diagram) GenerateContainers(n *diagram.Node, v corev1.Pod) {
+	if d.detail != DetailContainers {
+		return
+	}
+
+	tooltips := make([]string, 0, len(v.Spec.Containers))
+
+	for _, c := range podContainers(v) {
+		d.badge(n, c.badge())
+		tooltips = append(tooltips, c.tooltip())
+	}
+
+	n.Options.Attributes["tooltip"] = strings.Join(tooltips, "\\n")
+}
@@ -0,0 +1,144 @@
+package diagram
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestGenerateContainers(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	completed := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}
+	waiting := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}
+
+	status := func(name string, state corev1.ContainerState) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: state}
+	}
+
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{
+			{Name: "migrate", Image: "registry.example.com/shop/migrate:1.2"},
+			{Name: "istio-init", Image: "docker.io/istio/proxyv2:1.20.0"},
+			{Name: "log-shipper", Image: "fluent-bit:2.1"},
+		},
+		Containers: []corev1.Container{
+			{
+				Name:  "web",
+				Image: "registry.example.com/shop/web:3.4.1",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+				LivenessProbe: &corev1.Probe{Handler: corev1.Handler{
+					HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)},
+				}},
+				ReadinessProbe: &corev1.Probe{Handler: corev1.Handler{
+					TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")},
+				}},
+			},
+			{Name: "istio-proxy", Image: "docker.io/istio/proxyv2:1.20.0"},
+		},
+		EphemeralContainers: []corev1.EphemeralContainer{{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox:1.36"},
+		}},
+	}
+
+	istioStatus := `{"initContainers":["istio-init"],"containers":["istio-proxy"]}`
+
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   testNamespace,
+			Annotations: map[string]string{istioStatusAnnotation: istioStatus},
+		}
+	}
+
+	started := &corev1.Pod{
+		ObjectMeta: meta("started"),
+		Spec:       spec,
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			InitContainerStatuses: []corev1.ContainerStatus{
+				status("migrate", completed), status("istio-init", completed), status("log-shipper", running),
+			},
+			ContainerStatuses: []corev1.ContainerStatus{status("web", running), status("istio-proxy", running)},
+		},
+	}
+
+	// The init containers still running while the next ones wait are not told apart from native sidecars.
+	starting := &corev1.Pod{
+		ObjectMeta: meta("starting"),
+		Spec:       spec,
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			InitContainerStatuses: []corev1.ContainerStatus{
+				status("migrate", completed), status("istio-init", completed), status("log-shipper", running),
+			},
+			ContainerStatuses: []corev1.ContainerStatus{status("web", waiting), status("istio-proxy", waiting)},
+		},
+	}
+
+	d, err := NewTempDiagram("k8s", "Kubernetes", WithDetail(DetailContainers))
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, started, starting))
+
+	tests := []struct {
+		pod   string
+		lines []string
+	}{
+		{
+			pod: "started",
+			lines: []string{
+				"init migrate: migrate:1.2",
+				"init sidecar istio-init: proxyv2:1.20.0",
+				"init sidecar log-shipper: fluent-bit:2.1",
+				"web: web:3.4.1",
+				"sidecar istio-proxy: proxyv2:1.20.0",
+				"ephemeral debugger: busybox:1.36",
+			},
+		},
+		{
+			pod:   "starting",
+			lines: []string{"init log-shipper: fluent-bit:2.1"},
+		},
+	}
+
+	for _, test := range tests {
+		label := d.pods[test.pod].Options.Label
+
+		for _, line := range test.lines {
+			if !strings.Contains(label+"\\n", "\\n"+line+"\\n") {
+				t.Errorf("pod %s label %q doesn't list %s", test.pod, label, line)
+			}
+		}
+	}
+
+	tooltip := d.pods["started"].Options.Attributes["tooltip"]
+
+	for _, want := range []string{
+		"web: web:3.4.1\\n  image: registry.example.com/shop/web:3.4.1",
+		"  requests/limits: cpu 100m/-, memory 128Mi/256Mi",
+		"  liveness http :8080/healthz",
+		"  readiness tcp :http",
+		"ephemeral debugger: busybox:1.36\\n  image: busybox:1.36",
+	} {
+		if !strings.Contains(tooltip, want) {
+			t.Errorf("tooltip %q doesn't contain %q", tooltip, want)
+		}
+	}
+
+	if _, ok := generateFromFakeCluster(t, started).pods["started"].Options.Attributes["tooltip"]; ok {
+		t.Error("got a container tooltip without the containers detail level")
+	}
+}
@@ -1,72 +1,243 @@
 package diagram
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/trois-six/k8s-diagrams/pkg/discovery"
 	"github.com/blushft/go-diagrams/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	appsv1 "k8s.io/api/apps/v1"
 )
 
-const (
-	nodeFontSize  = 10
-	groupFontSize = 10
-	nodeWidth     = 1.2
-)
+// ErrRender is the reason of the errors returned when a diagram can't be rendered to its output directory, to be
+// checked with errors.Is.
+var ErrRender = errors.New("rendering diagram")
+
+type renderError struct {
+	err error
+}
+
+func (e renderError) Error() string {
+	return "rendering diagram: " + e.err.Error()
+}
 
+func (e renderError) Unwrap() error {
+	return e.err
+}
+
+func (e renderError) Is(target error) bool {
+	return target == ErrRender
+}
+
+// Diagram draws the objects of a namespace.
 type Diagram struct {
-	filename          string
-	outputDir         string
-	namespaceGroups   map[string]*diagram.Group
-	daemonSets        map[string]*diagram.Node
-	daemonSetGroups   map[string]*diagram.Group
-	deployments       map[string]*diagram.Node
-	endpoints         map[string]*diagram.Node
-	ingresses         map[string]*diagram.Node
-	internet          *diagram.Node
-	pods              map[string]*diagram.Node
-	replicaSets       map[string]*diagram.Node
-	replicaSetGroups  map[string]*diagram.Group
-	services          map[string]*diagram.Node
-	statefulSets      map[string]*diagram.Node
-	statefulSetGroups map[string]*diagram.Group
-	diag              *diagram.Diagram
+	filename           string
+	outputDir          string
+	label              string
+	tempDir            string
+	files              map[string][]byte
+	dot                []byte
+	renderErr          error
+	rendered           bool
+	style              Style
+	icons              map[string]string
+	objectLabels       map[string]map[string]string
+	include            []discovery.Rule
+	exclude            []discovery.Rule
+	showLegend         bool
+	clusterDomain      string
+	cluster            string
+	namespace          string
+	version            string
+	generatedAt        time.Time
+	highlighted        bool
+	showScaledToZero   bool
+	showOldReplicaSets bool
+	showRBAC           bool
+	showSecurity       bool
+	detail             DetailLevel
+	groupByHelmRelease bool
+	groupByLabels      []string
+	helmReleases       map[string]discovery.HelmRelease
+	namespaceGroups    map[string]*diagram.Group
+	releaseGroups      map[string]*diagram.Group
+	labelGroups        map[string]*diagram.Group
+	daemonSets         map[string]*diagram.Node
+	daemonSetGroups    map[string]*diagram.Group
+	deployments        map[string]*diagram.Node
+	rollouts           map[string]appsv1.Deployment
+	endpoints          map[string]*diagram.Node
+	externalHosts      map[string]*diagram.Node
+	ingresses          map[string]*diagram.Node
+	internet           *diagram.Node
+	pods               map[string]*diagram.Node
+	podLabels          map[string]map[string]string
+	podServiceAccounts map[string]string
+	replicaSets        map[string]*diagram.Node
+	replicaSetGroups   map[string]*diagram.Group
+	remoteServices     map[string]*diagram.Node
+	resources          map[string]*diagram.Node
+	roles              map[string]*diagram.Node
+	serviceAccounts    map[string]*diagram.Node
+	services           map[string]*diagram.Node
+	statefulSets       map[string]*diagram.Node
+	statefulSetGroups  map[string]*diagram.Group
+	inactiveWorkloads  []inactiveWorkload
+	badges             map[*diagram.Node][]string
+	keys               map[string]string
+	keyNodes           map[string][]*diagram.Node
+	graphNodes         map[string]GraphNode
+	diag               *diagram.Diagram
+}
+
+// Option configures a Diagram.
+type Option func(*Diagram)
+
+// ShowScaledToZero draws the workloads scaled to zero instead of hiding them.
+func ShowScaledToZero(show bool) Option {
+	return func(d *Diagram) {
+		d.showScaledToZero = show
+	}
+}
+
+// ShowOldReplicaSets draws the replicaSets left behind by deployment rollouts instead of hiding them.
+func ShowOldReplicaSets(show bool) Option {
+	return func(d *Diagram) {
+		d.showOldReplicaSets = show
+	}
+}
+
+// ShowRBAC links the pods to their service account, to the bindings granting it permissions and to the roles they
+// bind.
+func ShowRBAC(show bool) Option {
+	return func(d *Diagram) {
+		d.showRBAC = show
+	}
+}
+
+// WithDetail sets how much of the pods content is drawn.
+func WithDetail(level DetailLevel) Option {
+	return func(d *Diagram) {
+		d.detail = level
+	}
+}
+
+// WithFilter draws the objects matching one of the include rules, or all of them without include rules, and none of
+// the exclude rules.
+func WithFilter(include, exclude []discovery.Rule) Option {
+	return func(d *Diagram) {
+		d.include = include
+		d.exclude = exclude
+	}
+}
+
+// WithClusterDomain sets the domain the services of the cluster are named in, to link the ExternalName services to the
+// in-cluster services they point to. It defaults to discovery.DefaultClusterDomain.
+func WithClusterDomain(domain string) Option {
+	return func(d *Diagram) {
+		d.clusterDomain = domain
+	}
+}
+
+// NewDiagram creates a diagram rendered to the filename.dot file of the output directory. Options can override its
+// label.
+func NewDiagram(outputDir, filename, label string, opts ...Option) (*Diagram, error) {
+	dg := &Diagram{
+		filename:           filename,
+		outputDir:          outputDir,
+		label:              label,
+		style:              DefaultStyle(),
+		clusterDomain:      discovery.DefaultClusterDomain,
+		helmReleases:       make(map[string]discovery.HelmRelease),
+		namespaceGroups:    make(map[string]*diagram.Group),
+		releaseGroups:      make(map[string]*diagram.Group),
+		labelGroups:        make(map[string]*diagram.Group),
+		daemonSets:         make(map[string]*diagram.Node),
+		daemonSetGroups:    make(map[string]*diagram.Group),
+		deployments:        make(map[string]*diagram.Node),
+		rollouts:           make(map[string]appsv1.Deployment),
+		endpoints:          make(map[string]*diagram.Node),
+		externalHosts:      make(map[string]*diagram.Node),
+		ingresses:          make(map[string]*diagram.Node),
+		pods:               make(map[string]*diagram.Node),
+		podLabels:          make(map[string]map[string]string),
+		podServiceAccounts: make(map[string]string),
+		replicaSets:        make(map[string]*diagram.Node),
+		replicaSetGroups:   make(map[string]*diagram.Group),
+		remoteServices:     make(map[string]*diagram.Node),
+		resources:          make(map[string]*diagram.Node),
+		roles:              make(map[string]*diagram.Node),
+		serviceAccounts:    make(map[string]*diagram.Node),
+		services:           make(map[string]*diagram.Node),
+		statefulSets:       make(map[string]*diagram.Node),
+		statefulSetGroups:  make(map[string]*diagram.Group),
+		badges:             make(map[*diagram.Node][]string),
+		keys:               make(map[string]string),
+		keyNodes:           make(map[string][]*diagram.Node),
+		graphNodes:         make(map[string]GraphNode),
+		icons:              make(map[string]string),
+		objectLabels:       make(map[string]map[string]string),
+	}
+
+	for _, opt := range opts {
+		opt(dg)
+	}
+
+	if err := dg.style.validate(); err != nil {
+		return nil, err
+	}
+
+	d, err := dg.newGraph(dg.label)
+	if err != nil {
+		return nil, err
+	}
+
+	dg.diag = d
+
+	return dg, nil
 }
 
-func NewDiagram(outputDir, filename, label string) (*Diagram, error) {
-	d, err := diagram.New(
-		diagram.Filename(filename),
+// newGraph creates the root graph of the diagram with the label. Its attributes are set when it is created.
+func (d *Diagram) newGraph(label string) (*diagram.Diagram, error) {
+	g, err := diagram.New(
+		diagram.Filename(d.filename),
 		diagram.Label(label),
-		diagram.Direction("TB"),
+		diagram.Direction(d.style.Direction),
 		func(options *diagram.Options) {
-			options.Name = outputDir
-			options.Attributes["nodesep"] = "1"
-			options.Attributes["splines"] = "curved"
+			options.Name = d.outputDir
+			options.Attributes["nodesep"] = formatFloat(d.style.NodeSep)
+			options.Attributes["ranksep"] = formatFloat(d.style.RankSep)
+			options.Attributes["splines"] = d.style.Splines
+
+			if d.style.Background != "" {
+				options.Attributes["bgcolor"] = d.style.Background
+			}
+
+			if d.style.FontColor != "" {
+				options.Font.Color = d.style.FontColor
+			}
 		},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("creating diagram: %w", err)
 	}
 
-	return &Diagram{
-		filename:          filename,
-		outputDir:         outputDir,
-		namespaceGroups:   make(map[string]*diagram.Group),
-		daemonSets:        make(map[string]*diagram.Node),
-		daemonSetGroups:   make(map[string]*diagram.Group),
-		deployments:       make(map[string]*diagram.Node),
-		endpoints:         make(map[string]*diagram.Node),
-		ingresses:         make(map[string]*diagram.Node),
-		pods:              make(map[string]*diagram.Node),
-		replicaSets:       make(map[string]*diagram.Node),
-		replicaSetGroups:  make(map[string]*diagram.Group),
-		services:          make(map[string]*diagram.Node),
-		statefulSets:      make(map[string]*diagram.Node),
-		statefulSetGroups: make(map[string]*diagram.Group),
-		diag:              d,
-	}, nil
+	return g, nil
 }
 
 func (d *Diagram) GenerateDiagram(namespace string, o *discovery.Objects) {
+	if len(d.include) > 0 || len(d.exclude) > 0 {
+		o = o.Filter(d.include, d.exclude)
+	}
+
+	d.namespace = namespace
+	d.generatedAt = time.Now().UTC()
+
+	if o.Version != nil {
+		d.version = o.Version.Original()
+	}
+
 	for _, ns := range o.Namespaces.Items {
 		if ns.Name != namespace {
 			continue
@@ -74,26 +245,36 @@ func (d *Diagram) GenerateDiagram(namespace string, o *discovery.Objects) {
 
 		d.namespaceGroups[ns.Name] = diagram.NewGroup(ns.Name, func(o *diagram.GroupOptions) {
 			o.Font = diagram.Font{
-				Size: groupFontSize,
+				Size: d.style.GroupFontSize,
 			}
-			o.BackgroundColor = "#E0ECF4"
+			o.BackgroundColor = d.style.NamespaceColor
 		}).Label(ns.Name)
 		d.diag.Group(d.namespaceGroups[ns.Name])
 
+		for _, r := range o.HelmReleases {
+			if r.Namespace == namespace {
+				d.helmReleases[r.Name] = r
+			}
+		}
+
 		d.GenerateDeployments(namespace, o.Deployments)
 		d.GenerateDaemonSets(namespace, o.DaemonSets)
 		d.GenerateReplicaSets(namespace, o.ReplicaSets)
 		d.GenerateStatefulSets(namespace, o.StatefulSets)
 		d.GeneratePods(namespace, o.Pods)
-		d.GenerateServices(namespace, o.Services, o.Endpoints)
+		d.GenerateServices(namespace, o.Services, o.Endpoints, o.EndpointSlices)
+		d.GenerateExternalNames(namespace, o.Services, o.ReferencedServices)
 		d.GenerateIngresses(namespace, o.Ingresses)
+		d.GenerateRBAC(namespace, o)
+		d.GenerateSecurity(namespace, o)
 	}
+
+	d.applyBadges()
 }
 
+// RenderDiagram writes the diagram to the filename.dot file of the output directory, along with the icons it uses.
 func (d *Diagram) RenderDiagram() error {
-	if err := d.diag.Render(); err != nil {
-		return fmt.Errorf("rendering diagram: %w", err)
-	}
+	_, err := d.render()
 
-	return nil
+	return err
 }
@@ -0,0 +1,249 @@
+package diagram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+)
+
+// Change tells how an object or a link differs between two graphs.
+type Change string
+
+const (
+	// ChangeAdded is an object or a link only found in the newer graph.
+	ChangeAdded Change = "added"
+	// ChangeRemoved is an object or a link only found in the older graph.
+	ChangeRemoved Change = "removed"
+	// ChangeChanged is an object whose images, replicas or ports changed, or a link whose label changed.
+	ChangeChanged Change = "changed"
+)
+
+var (
+	changeFillColors = map[Change]string{
+		ChangeAdded:   "#82E0AA",
+		ChangeRemoved: "#F1948A",
+		ChangeChanged: "#F8C471",
+	}
+	changeEdgeColors = map[Change]string{
+		ChangeAdded:   "#1E8449",
+		ChangeRemoved: "#C0392B",
+		ChangeChanged: "#D68910",
+	}
+)
+
+// NodeChange is an object that differs between two graphs. Details list its changed attributes as
+// name: old → new.
+type NodeChange struct {
+	Key     string   `json:"key"`
+	Change  Change   `json:"change"`
+	Details []string `json:"details,omitempty"`
+}
+
+// EdgeChange is a link that differs between two graphs. Details describe its label change.
+type EdgeChange struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Change  Change   `json:"change"`
+	Details []string `json:"details,omitempty"`
+}
+
+// GraphDiff lists the differences between two graphs, sorted by key.
+type GraphDiff struct {
+	Nodes []NodeChange `json:"nodes,omitempty"`
+	Edges []EdgeChange `json:"edges,omitempty"`
+}
+
+// Empty tells if both graphs are the same.
+func (g GraphDiff) Empty() bool {
+	return len(g.Nodes) == 0 && len(g.Edges) == 0
+}
+
+var changeSigns = map[Change]string{
+	ChangeAdded:   "+",
+	ChangeRemoved: "-",
+	ChangeChanged: "~",
+}
+
+// String reports the differences one per line, prefixed with + when added, - when removed and ~ when changed.
+func (g GraphDiff) String() string {
+	lines := []string{fmt.Sprintf("%d objects and %d links differ:", len(g.Nodes), len(g.Edges))}
+
+	for _, c := range g.Nodes {
+		line := changeSigns[c.Change] + " " + c.Key
+		if len(c.Details) > 0 {
+			line += " (" + strings.Join(c.Details, ", ") + ")"
+		}
+
+		lines = append(lines, line)
+	}
+
+	for _, c := range g.Edges {
+		line := changeSigns[c.Change] + " " + c.From + " -> " + c.To
+		if len(c.Details) > 0 {
+			line += " (" + strings.Join(c.Details, ", ") + ")"
+		}
+
+		lines = append(lines, line)
+	}
+
+	// Labels spanning several lines are reported on one.
+	return strings.ReplaceAll(strings.Join(lines, "\n"), "\\n", "; ")
+}
+
+// CompareGraphs lists the objects and links added, removed or changed from older to newer.
+func CompareGraphs(older, newer Graph) GraphDiff {
+	var diff GraphDiff
+
+	olderNodes := make(map[string]GraphNode, len(older.Nodes))
+	for _, n := range older.Nodes {
+		olderNodes[n.Key] = n
+	}
+
+	newerNodes := make(map[string]GraphNode, len(newer.Nodes))
+	for _, n := range newer.Nodes {
+		newerNodes[n.Key] = n
+
+		o, ok := olderNodes[n.Key]
+		if !ok {
+			diff.Nodes = append(diff.Nodes, NodeChange{Key: n.Key, Change: ChangeAdded})
+
+			continue
+		}
+
+		if details := attributesChanges(o.Attributes, n.Attributes); len(details) > 0 {
+			diff.Nodes = append(diff.Nodes, NodeChange{Key: n.Key, Change: ChangeChanged, Details: details})
+		}
+	}
+
+	for _, n := range older.Nodes {
+		if _, ok := newerNodes[n.Key]; !ok {
+			diff.Nodes = append(diff.Nodes, NodeChange{Key: n.Key, Change: ChangeRemoved})
+		}
+	}
+
+	olderEdges := make(map[[2]string]GraphEdge, len(older.Edges))
+	for _, e := range older.Edges {
+		olderEdges[[2]string{e.From, e.To}] = e
+	}
+
+	newerEdges := make(map[[2]string]bool, len(newer.Edges))
+
+	for _, e := range newer.Edges {
+		newerEdges[[2]string{e.From, e.To}] = true
+
+		o, ok := olderEdges[[2]string{e.From, e.To}]
+
+		switch {
+		case !ok:
+			diff.Edges = append(diff.Edges, EdgeChange{From: e.From, To: e.To, Change: ChangeAdded})
+		case o.Label != e.Label:
+			diff.Edges = append(diff.Edges, EdgeChange{
+				From: e.From, To: e.To, Change: ChangeChanged, Details: []string{change("label", o.Label, e.Label)},
+			})
+		}
+	}
+
+	for _, e := range older.Edges {
+		if !newerEdges[[2]string{e.From, e.To}] {
+			diff.Edges = append(diff.Edges, EdgeChange{From: e.From, To: e.To, Change: ChangeRemoved})
+		}
+	}
+
+	sort.Slice(diff.Nodes, func(i, j int) bool {
+		return diff.Nodes[i].Key < diff.Nodes[j].Key
+	})
+
+	sort.Slice(diff.Edges, func(i, j int) bool {
+		if diff.Edges[i].From != diff.Edges[j].From {
+			return diff.Edges[i].From < diff.Edges[j].From
+		}
+
+		return diff.Edges[i].To < diff.Edges[j].To
+	})
+
+	return diff
+}
+
+func attributesChanges(older, newer map[string]string) []string {
+	var details []string
+
+	for name, value := range newer {
+		if older[name] != value {
+			details = append(details, change(name, older[name], value))
+		}
+	}
+
+	for name, value := range older {
+		if _, ok := newer[name]; !ok {
+			details = append(details, change(name, value, ""))
+		}
+	}
+
+	sort.Strings(details)
+
+	return details
+}
+
+func change(name, older, newer string) string {
+	return name + ": " + older + " → " + newer
+}
+
+// Highlight colors the objects and links of the diagram that differ between two graphs: added ones in green, removed
+// ones in red and changed ones in amber, with their changes as badges. The other objects lose their health colors.
+// Removed links are drawn when the diagram doesn't already link their objects.
+func (d *Diagram) Highlight(diff GraphDiff) {
+	d.highlighted = true
+
+	for _, nodes := range d.keyNodes {
+		for _, n := range nodes {
+			delete(n.Options.Attributes, "style")
+			delete(n.Options.Attributes, "fillcolor")
+		}
+	}
+
+	for _, c := range diff.Nodes {
+		for _, n := range d.keyNodes[c.Key] {
+			n.Options.Attributes["style"] = "filled"
+			n.Options.Attributes["fillcolor"] = changeFillColors[c.Change]
+
+			for _, detail := range c.Details {
+				d.badge(n, detail)
+			}
+		}
+	}
+
+	edges := make(map[[2]string][]*diagram.Edge)
+
+	for _, e := range d.allEdges() {
+		pair := [2]string{d.keys[e.Start()], d.keys[e.End()]}
+		edges[pair] = append(edges[pair], e)
+	}
+
+	for _, c := range diff.Edges {
+		from, to := d.keyNodes[c.From], d.keyNodes[c.To]
+		if len(from) == 0 || len(to) == 0 {
+			continue
+		}
+
+		color := func(o *diagram.EdgeOptions) {
+			o.Color = changeEdgeColors[c.Change]
+			o.Attributes["penwidth"] = "2"
+		}
+
+		if existing := edges[[2]string{c.From, c.To}]; len(existing) > 0 {
+			for _, e := range existing {
+				color(&e.Options)
+			}
+
+			continue
+		}
+
+		d.diag.ConnectByID(from[0].ID(), to[0].ID(), color, func(o *diagram.EdgeOptions) {
+			o.Style = "dashed"
+		})
+	}
+
+	d.applyBadges()
+}
@@ -0,0 +1,107 @@
+package diagram
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func diffObjects(image, service string) []runtime.Object {
+	return []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: image}}},
+			}},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: service, Namespace: testNamespace}},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: testNamespace},
+			Spec: networkingv1.IngressSpec{
+				DefaultBackend: &networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{Name: service},
+				},
+			},
+		},
+	}
+}
+
+func TestCompareGraphs(t *testing.T) {
+	older := generateFromFakeCluster(t, diffObjects("nginx:1.20", "web")...).Graph()
+	newer := generateFromFakeCluster(t, diffObjects("nginx:1.21", "api")...).Graph()
+
+	diff := CompareGraphs(older, newer)
+
+	wantNodes := []NodeChange{
+		{Key: "Deployment/app/web", Change: ChangeChanged, Details: []string{"images: nginx:1.20 → nginx:1.21"}},
+		{Key: "Service/app/api", Change: ChangeAdded},
+		{Key: "Service/app/web", Change: ChangeRemoved},
+	}
+	if !reflect.DeepEqual(diff.Nodes, wantNodes) {
+		t.Errorf("got node changes %+v, want %+v", diff.Nodes, wantNodes)
+	}
+
+	wantEdges := []EdgeChange{
+		{From: "Ingress/app/ing", To: "Service/app/api", Change: ChangeAdded},
+		{From: "Ingress/app/ing", To: "Service/app/web", Change: ChangeRemoved},
+	}
+	if !reflect.DeepEqual(diff.Edges, wantEdges) {
+		t.Errorf("got edge changes %+v, want %+v", diff.Edges, wantEdges)
+	}
+
+	if !CompareGraphs(newer, newer).Empty() {
+		t.Error("got changes between a graph and itself")
+	}
+}
+
+func TestHighlightRemovedEdge(t *testing.T) {
+	d := generateFromFakeCluster(t, append(diffObjects("nginx:1.21", "api"), &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+	})...)
+
+	d.Highlight(GraphDiff{Edges: []EdgeChange{
+		{From: "Ingress/app/ing", To: "Service/app/web", Change: ChangeRemoved},
+	}})
+
+	edges := 0
+
+	for _, e := range d.diag.Edges() {
+		if e.Start() == d.ingresses["ing"].ID() && e.End() == d.services["web"].ID() {
+			edges++
+
+			if e.Options.Color != changeEdgeColors[ChangeRemoved] {
+				t.Errorf("got removed edge color %q, want %q", e.Options.Color, changeEdgeColors[ChangeRemoved])
+			}
+		}
+	}
+
+	if edges != 1 {
+		t.Errorf("got %d removed edges drawn, want 1", edges)
+	}
+}
+
+func TestGraphDiffString(t *testing.T) {
+	diff := GraphDiff{
+		Nodes: []NodeChange{
+			{Key: "Service/app/api", Change: ChangeAdded},
+			{Key: "Deployment/app/web", Change: ChangeChanged, Details: []string{"replicas: 2 → 3"}},
+		},
+		Edges: []EdgeChange{
+			{From: "Ingress/app/ing", To: "Service/app/web", Change: ChangeChanged, Details: []string{"label: a\\nb → a"}},
+		},
+	}
+
+	want := `2 objects and 1 links differ:
++ Service/app/api
+~ Deployment/app/web (replicas: 2 → 3)
+~ Ingress/app/ing -> Service/app/web (label: a; b → a)`
+
+	if got := diff.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package diagram
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const notReadyColor = "#E59866"
+
+// serviceBackend is a pod backing a service, as listed by its endpoints.
+type serviceBackend struct {
+	pod   string
+	ready bool
+	ports string
+}
+
+// serviceBackends lists the pods backing a service, from its EndpointSlices when they were discovered, or from its
+// Endpoints otherwise.
+func serviceBackends(
+	svc corev1.Service, endpoints *corev1.EndpointsList, slices *discoveryv1.EndpointSliceList,
+) []serviceBackend {
+	if slices != nil {
+		return endpointSliceBackends(svc, slices)
+	}
+
+	if endpoints != nil {
+		return endpointsBackends(svc, endpoints)
+	}
+
+	return nil
+}
+
+func endpointsBackends(svc corev1.Service, endpoints *corev1.EndpointsList) []serviceBackend {
+	var backends []serviceBackend
+
+	for _, ep := range endpoints.Items {
+		if ep.Namespace != svc.Namespace || ep.Name != svc.Name {
+			continue
+		}
+
+		for _, subset := range ep.Subsets {
+			ports := servicePortsLabel(svc, subset.Ports)
+
+			for _, address := range subset.Addresses {
+				if pod := podName(address.TargetRef); pod != "" {
+					backends = append(backends, serviceBackend{pod: pod, ready: true, ports: ports})
+				}
+			}
+
+			for _, address := range subset.NotReadyAddresses {
+				if pod := podName(address.TargetRef); pod != "" {
+					backends = append(backends, serviceBackend{pod: pod, ports: ports})
+				}
+			}
+		}
+	}
+
+	return backends
+}
+
+func endpointSliceBackends(svc corev1.Service, slices *discoveryv1.EndpointSliceList) []serviceBackend {
+	var backends []serviceBackend
+
+	for _, slice := range slices.Items {
+		if slice.Namespace != svc.Namespace || slice.Labels[discoveryv1.LabelServiceName] != svc.Name {
+			continue
+		}
+
+		ports := servicePortsLabel(svc, endpointSlicePorts(slice.Ports))
+
+		for _, ep := range slice.Endpoints {
+			if pod := podName(ep.TargetRef); pod != "" {
+				// A nil ready condition has to be interpreted as ready.
+				ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+
+				backends = append(backends, serviceBackend{pod: pod, ready: ready, ports: ports})
+			}
+		}
+	}
+
+	return backends
+}
+
+func endpointSlicePorts(ports []discoveryv1.EndpointPort) []corev1.EndpointPort {
+	converted := make([]corev1.EndpointPort, 0, len(ports))
+
+	for _, p := range ports {
+		var ep corev1.EndpointPort
+
+		if p.Name != nil {
+			ep.Name = *p.Name
+		}
+
+		if p.Port != nil {
+			ep.Port = *p.Port
+		}
+
+		if p.Protocol != nil {
+			ep.Protocol = *p.Protocol
+		}
+
+		converted = append(converted, ep)
+	}
+
+	return converted
+}
+
+func podName(ref *corev1.ObjectReference) string {
+	if ref == nil || strings.ToLower(ref.Kind) != "pod" {
+		return ""
+	}
+
+	return ref.Name
+}
+
+// selectedPods lists the drawn pods matching the selector of a service.
+func (d *Diagram) selectedPods(svc corev1.Service) []string {
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	var pods []string
+
+	for name, l := range d.podLabels {
+		if selector.Matches(labels.Set(l)) {
+			pods = append(pods, name)
+		}
+	}
+
+	sort.Strings(pods)
+
+	return pods
+}
+
+// notReadyEdge styles the edges reaching pods selected by a service but not ready to receive its traffic.
+func notReadyEdge(o *diagram.EdgeOptions) {
+	o.Style = "dashed"
+	o.Color = notReadyColor
+}
@@ -0,0 +1,124 @@
+package diagram
+
+import (
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/blushft/go-diagrams/nodes/generic"
+	"github.com/blushft/go-diagrams/nodes/k8s"
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// GenerateExternalNames links the ExternalName services of a namespace to the in-cluster services they point to,
+// possibly in other namespaces, or to the external hosts they resolve to. The services of other namespaces are read
+// from the referenced services.
+func (d *Diagram) GenerateExternalNames(namespace string, services, referenced *corev1.ServiceList) {
+	for _, svc := range services.Items {
+		if svc.Namespace != namespace || svc.Spec.Type != corev1.ServiceTypeExternalName {
+			continue
+		}
+
+		if _, ok := d.services[svc.Name]; !ok {
+			continue
+		}
+
+		target := d.GenerateExternalHost(svc.Spec.ExternalName)
+
+		if name, ns, ok := discovery.ServiceReference(svc.Spec.ExternalName, d.clusterDomain); ok {
+			target = d.GenerateServiceReference(namespace, ns, name, services, referenced)
+		}
+
+		d.diag.Connect(d.services[svc.Name], target, edgeLabel("ExternalName"))
+	}
+}
+
+// GenerateServiceReference returns the node of a service referenced by an ExternalName service, found in the services
+// of the namespace or in the referenced ones. Services of other namespaces are drawn in their own namespace group.
+func (d *Diagram) GenerateServiceReference(
+	namespace, refNamespace, name string, services, referenced *corev1.ServiceList,
+) *diagram.Node {
+	if refNamespace == namespace {
+		if n, ok := d.services[name]; ok {
+			return n
+		}
+	}
+
+	key := refNamespace + "/" + name
+	if n, ok := d.remoteServices[key]; ok {
+		return n
+	}
+
+	log.Debug().Msgf("Generating referenced service: %s", key)
+
+	d.remoteServices[key] = k8s.Network.Svc(
+		diagram.NodeLabel(name),
+		diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+		diagram.Width(d.style.NodeWidth),
+	)
+	d.remoteNamespaceGroup(refNamespace).Add(d.remoteServices[key])
+	d.register(d.remoteServices[key], "Service", refNamespace, name, nil)
+
+	found := false
+
+	for _, svc := range append(serviceItems(services), serviceItems(referenced)...) {
+		if svc.Namespace == refNamespace && svc.Name == name {
+			d.badge(d.remoteServices[key], serviceTypeBadge(svc))
+
+			found = true
+		}
+	}
+
+	if !found {
+		paint(d.remoteServices[key], healthCritical)
+		d.badge(d.remoteServices[key], "not found")
+	}
+
+	return d.remoteServices[key]
+}
+
+// GenerateExternalHost returns the node of a host outside of the cluster.
+func (d *Diagram) GenerateExternalHost(host string) *diagram.Node {
+	if n, ok := d.externalHosts[host]; ok {
+		return n
+	}
+
+	log.Debug().Msgf("Generating external host: %s", host)
+
+	d.externalHosts[host] = generic.Place.Datacenter(
+		diagram.NodeLabel(host),
+		diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+		diagram.Width(d.style.NodeWidth),
+	)
+	d.diag.Add(d.externalHosts[host])
+	d.register(d.externalHosts[host], "ExternalHost", "", host, nil)
+
+	return d.externalHosts[host]
+}
+
+// remoteNamespaceGroup returns the group of a namespace only drawn for the objects referenced from the diagram
+// namespace.
+func (d *Diagram) remoteNamespaceGroup(namespace string) *diagram.Group {
+	if g, ok := d.namespaceGroups[namespace]; ok {
+		return g
+	}
+
+	d.namespaceGroups[namespace] = diagram.NewGroup(namespace, func(o *diagram.GroupOptions) {
+		o.Font = diagram.Font{
+			Size: d.style.GroupFontSize,
+		}
+		o.BackgroundColor = d.style.RemoteNamespaceColor
+		o.Style = "rounded,dashed"
+	}).Label(namespace)
+	d.diag.Group(d.namespaceGroups[namespace])
+
+	return d.namespaceGroups[namespace]
+}
+
+// serviceItems returns the services of a list, which may not have been discovered.
+func serviceItems(services *corev1.ServiceList) []corev1.Service {
+	if services == nil {
+		return nil
+	}
+
+	return services.Items
+}
@@ -0,0 +1,54 @@
+package diagram
+
+// Flag marks an object of the diagram, identified by its Kind/namespace/name key, with a problem found on it.
+// Critical flags are painted in red, the other ones in amber.
+type Flag struct {
+	Key      string
+	Message  string
+	Critical bool
+}
+
+// NodeKey returns the key identifying an object in the graph of a diagram.
+func NodeKey(kind, namespace, name string) string {
+	return nodeKey(kind, namespace, name)
+}
+
+// Flag colors the objects of the diagram having problems, with their messages as badges. The other objects lose
+// their health colors. It returns the flags whose objects aren't drawn.
+func (d *Diagram) Flag(flags []Flag) []Flag {
+	for _, nodes := range d.keyNodes {
+		for _, n := range nodes {
+			delete(n.Options.Attributes, "style")
+			delete(n.Options.Attributes, "fillcolor")
+		}
+	}
+
+	var missing []Flag
+
+	for _, f := range flags {
+		nodes := d.keyNodes[f.Key]
+		if len(nodes) == 0 {
+			missing = append(missing, f)
+
+			continue
+		}
+
+		h := healthWarning
+		if f.Critical {
+			h = healthCritical
+		}
+
+		for _, n := range nodes {
+			// A critical flag wins over a warning one on the same object.
+			if n.Options.Attributes["fillcolor"] != criticalColor {
+				paint(n, h)
+			}
+
+			d.badge(n, f.Message)
+		}
+	}
+
+	d.applyBadges()
+
+	return missing
+}
@@ -0,0 +1,21 @@
+package diagram
+
+import "testing"
+
+func TestFlag(t *testing.T) {
+	d := generateFromFakeCluster(t, diffObjects("nginx:1.21", "web")...)
+
+	missing := d.Flag([]Flag{
+		{Key: NodeKey("Service", testNamespace, "web"), Message: "service-no-endpoints"},
+		{Key: NodeKey("Service", testNamespace, "web"), Message: "loadbalancer-pending", Critical: true},
+		{Key: NodeKey("Service", testNamespace, "api"), Message: "service-no-endpoints"},
+	})
+
+	if len(missing) != 1 || missing[0].Key != "Service/app/api" {
+		t.Errorf("got missing flags %+v, want Service/app/api", missing)
+	}
+
+	if got := d.services["web"].Options.Attributes["fillcolor"]; got != criticalColor {
+		t.Errorf("got service color %q, want %q", got, criticalColor)
+	}
+}
@@ -0,0 +1,231 @@
+package diagram
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Graph is the topology a diagram draws: the objects it shows and the links between them. Object names are stable
+// across rollouts, the hashes controllers add to the names of replicaSets and pods being replaced with a *.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is an object of a graph, along with the attributes compared between graphs: images, replicas, ports.
+type GraphNode struct {
+	Key        string            `json:"key"`
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// GraphEdge is a link between two objects of a graph, identified by their keys.
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+func nodeKey(kind, namespace, name string) string {
+	if namespace == "" {
+		return kind + "/" + name
+	}
+
+	return kind + "/" + namespace + "/" + name
+}
+
+// register records the object a node draws, to build the graph of the diagram.
+func (d *Diagram) register(n *diagram.Node, kind, namespace, name string, attributes map[string]string) {
+	key := nodeKey(kind, namespace, name)
+
+	d.keys[n.ID()] = key
+	d.keyNodes[key] = append(d.keyNodes[key], n)
+
+	if _, ok := d.graphNodes[key]; !ok {
+		d.graphNodes[key] = GraphNode{Key: key, Kind: kind, Namespace: namespace, Name: name, Attributes: attributes}
+	}
+}
+
+// allEdges lists the edges of the diagram and of all its groups.
+func (d *Diagram) allEdges() []*diagram.Edge {
+	edges := d.diag.Edges()
+	groups := d.diag.Groups()
+
+	for len(groups) > 0 {
+		g := groups[0]
+		groups = append(groups[1:], g.Children()...)
+		edges = append(edges, g.Edges()...)
+	}
+
+	return edges
+}
+
+// Graph returns the objects drawn by the diagram and the links between them, sorted by key.
+func (d *Diagram) Graph() Graph {
+	g := Graph{Nodes: make([]GraphNode, 0, len(d.graphNodes))}
+
+	for _, n := range d.graphNodes {
+		g.Nodes = append(g.Nodes, n)
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Key < g.Nodes[j].Key
+	})
+
+	// Edges between the pods sharing a stable name are merged, along with their labels.
+	labels := make(map[[2]string]map[string]bool)
+
+	for _, e := range d.allEdges() {
+		from, okFrom := d.keys[e.Start()]
+		to, okTo := d.keys[e.End()]
+
+		if !okFrom || !okTo {
+			continue
+		}
+
+		pair := [2]string{from, to}
+		if labels[pair] == nil {
+			labels[pair] = make(map[string]bool)
+		}
+
+		if e.Options.Label != "" {
+			labels[pair][e.Options.Label] = true
+		}
+	}
+
+	for pair, set := range labels {
+		l := make([]string, 0, len(set))
+		for label := range set {
+			l = append(l, label)
+		}
+
+		sort.Strings(l)
+
+		g.Edges = append(g.Edges, GraphEdge{From: pair[0], To: pair[1], Label: strings.Join(l, ", ")})
+	}
+
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+
+		return g.Edges[i].To < g.Edges[j].To
+	})
+
+	return g
+}
+
+// Without returns the graph without the objects of the given kinds, and their links.
+func (g Graph) Without(kinds ...string) Graph {
+	removed := make(map[string]bool)
+	filtered := Graph{}
+
+	for _, n := range g.Nodes {
+		if contains(kinds, n.Kind) {
+			removed[n.Key] = true
+
+			continue
+		}
+
+		filtered.Nodes = append(filtered.Nodes, n)
+	}
+
+	for _, e := range g.Edges {
+		if !removed[e.From] && !removed[e.To] {
+			filtered.Edges = append(filtered.Edges, e)
+		}
+	}
+
+	return filtered
+}
+
+// imagesAttributes describes the images of the containers of a pod template.
+func imagesAttributes(t corev1.PodTemplateSpec) map[string]string {
+	images := make([]string, 0, len(t.Spec.Containers))
+	for _, c := range t.Spec.Containers {
+		images = append(images, c.Image)
+	}
+
+	return map[string]string{"images": strings.Join(images, ", ")}
+}
+
+// replicasAttributes describes the images of the containers of a pod template, and its desired replicas.
+func replicasAttributes(t corev1.PodTemplateSpec, replicas *int32) map[string]string {
+	attributes := imagesAttributes(t)
+	attributes["replicas"] = strconv.Itoa(int(desiredReplicas(replicas)))
+
+	return attributes
+}
+
+// serviceAttributes describes the type of a service and its port→targetPort/protocol mappings.
+func serviceAttributes(svc corev1.Service) map[string]string {
+	ports := make([]string, 0, len(svc.Spec.Ports))
+
+	for _, p := range svc.Spec.Ports {
+		target := p.TargetPort.String()
+		if p.TargetPort.IntVal == 0 && p.TargetPort.StrVal == "" {
+			target = strconv.Itoa(int(p.Port))
+		}
+
+		ports = append(ports, fmt.Sprintf("%d→%s/%s", p.Port, target, protocol(p.Protocol)))
+	}
+
+	return map[string]string{"type": serviceTypeBadge(svc), "ports": strings.Join(ports, ", ")}
+}
+
+// WithoutAttributes returns the graph without the given attributes of its objects, such as replicas when they are
+// scaled automatically.
+func (g Graph) WithoutAttributes(names ...string) Graph {
+	filtered := Graph{Nodes: make([]GraphNode, 0, len(g.Nodes)), Edges: g.Edges}
+
+	for _, n := range g.Nodes {
+		attributes := make(map[string]string, len(n.Attributes))
+
+		for name, value := range n.Attributes {
+			if !contains(names, name) {
+				attributes[name] = value
+			}
+		}
+
+		if len(attributes) == 0 {
+			attributes = nil
+		}
+
+		n.Attributes = attributes
+		filtered.Nodes = append(filtered.Nodes, n)
+	}
+
+	return filtered
+}
+
+// SaveGraph writes a graph as JSON.
+func SaveGraph(w io.Writer, g Graph) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(g); err != nil {
+		return fmt.Errorf("encoding graph: %w", err)
+	}
+
+	return nil
+}
+
+// LoadGraph reads a graph saved by SaveGraph.
+func LoadGraph(r io.Reader) (Graph, error) {
+	var g Graph
+
+	if err := json.NewDecoder(r).Decode(&g); err != nil {
+		return Graph{}, fmt.Errorf("decoding graph: %w", err)
+	}
+
+	return g, nil
+}
@@ -0,0 +1,32 @@
+package diagram
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSaveGraph(t *testing.T) {
+	g := generateFromFakeCluster(t, diffObjects("nginx:1.21", "web")...).Graph().WithoutAttributes("replicas")
+
+	for _, n := range g.Nodes {
+		if _, ok := n.Attributes["replicas"]; ok {
+			t.Errorf("got replicas attribute for %s", n.Key)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if err := SaveGraph(&buf, g); err != nil {
+		t.Fatalf("SaveGraph() error = %v", err)
+	}
+
+	loaded, err := LoadGraph(&buf)
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded, g) {
+		t.Errorf("got graph %+v, want %+v", loaded, g)
+	}
+}
@@ -0,0 +1,62 @@
+package diagram
+
+import (
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecommendedGroupLabels are the recommended application labels, from the widest to the narrowest grouping.
+func RecommendedGroupLabels() []string {
+	return []string{"app.kubernetes.io/part-of", "app.kubernetes.io/name", "app.kubernetes.io/component"}
+}
+
+// GroupByLabels nests the objects inside one group per value of each label key, in the given order. Objects
+// without one of the labels skip its level.
+func GroupByLabels(keys ...string) Option {
+	return func(d *Diagram) {
+		d.groupByLabels = keys
+	}
+}
+
+// parentGroup returns the group the top-level nodes of an object are added to: the innermost group of its labels,
+// inside the group of its Helm release, inside its namespace group.
+func (d *Diagram) parentGroup(namespace string, meta metav1.ObjectMeta) *diagram.Group {
+	g := d.releaseGroup(namespace, meta)
+	path := namespace
+
+	if release := helmReleaseName(meta); d.groupByHelmRelease && release != "" {
+		path += "-release-" + release
+	}
+
+	for _, key := range d.groupByLabels {
+		value := meta.Labels[key]
+		if value == "" {
+			continue
+		}
+
+		path += "-" + key + "-" + value
+
+		if lg, ok := d.labelGroups[path]; ok {
+			g = lg
+
+			continue
+		}
+
+		log.Debug().Msgf("Generating label group: %s=%s", key, value)
+
+		d.labelGroups[path] = diagram.NewGroup(path, func(o *diagram.GroupOptions) {
+			o.Font = diagram.Font{
+				Size: d.style.GroupFontSize,
+			}
+			o.BackgroundColor = d.style.LabelGroupColor
+		}).Label(key[strings.LastIndex(key, "/")+1:] + ": " + value)
+		g.Group(d.labelGroups[path])
+
+		g = d.labelGroups[path]
+	}
+
+	return g
+}
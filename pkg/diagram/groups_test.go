@@ -0,0 +1,48 @@
+package diagram
+
+import (
+	"testing"
+
+	"github.com/blushft/go-diagrams/diagram"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParentGroupByLabels(t *testing.T) {
+	d, err := NewDiagram(t.TempDir(), "k8s", "Kubernetes", GroupByLabels(RecommendedGroupLabels()...))
+	if err != nil {
+		t.Fatalf("NewDiagram() error = %v", err)
+	}
+
+	d.namespaceGroups[testNamespace] = diagram.NewGroup(testNamespace)
+
+	labels := func(l map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Labels: l}
+	}
+
+	api := d.parentGroup(testNamespace, labels(map[string]string{
+		"app.kubernetes.io/part-of":   "checkout",
+		"app.kubernetes.io/name":      "api",
+		"app.kubernetes.io/component": "server",
+	}))
+	db := d.parentGroup(testNamespace, labels(map[string]string{
+		"app.kubernetes.io/part-of": "checkout",
+		"app.kubernetes.io/name":    "db",
+	}))
+	checkout := d.parentGroup(testNamespace, labels(map[string]string{"app.kubernetes.io/part-of": "checkout"}))
+
+	if api == db || api == checkout || db == checkout {
+		t.Error("objects of different applications added to the same group")
+	}
+
+	if got := len(checkout.Children()); got != 2 {
+		t.Errorf("got %d groups in the part-of group, want the api and db groups", got)
+	}
+
+	if got := len(d.namespaceGroups[testNamespace].Children()); got != 1 {
+		t.Errorf("got %d groups in the namespace group, want the part-of group only", got)
+	}
+
+	if got := d.parentGroup(testNamespace, labels(nil)); got != d.namespaceGroups[testNamespace] {
+		t.Error("object without labels not added to the namespace group")
+	}
+}
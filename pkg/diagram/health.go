@@ -0,0 +1,242 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	badgeHeight = 0.2
+
+	healthyColor   = "#D5F5E3"
+	pendingColor   = "#FCF3CF"
+	warningColor   = "#FAD7A0"
+	criticalColor  = "#F5B7B1"
+	completedColor = "#E5E7E9"
+	idleColor      = "#F4F6F7"
+	idleFontColor  = "#7B8894"
+)
+
+type health int
+
+const (
+	healthUnknown health = iota
+	healthHealthy
+	healthPending
+	healthWarning
+	healthCritical
+	healthCompleted
+	healthIdle
+)
+
+func (h health) color() string {
+	switch h {
+	case healthHealthy:
+		return healthyColor
+	case healthPending:
+		return pendingColor
+	case healthWarning:
+		return warningColor
+	case healthCritical:
+		return criticalColor
+	case healthCompleted:
+		return completedColor
+	case healthIdle:
+		return idleColor
+	case healthUnknown:
+	}
+
+	return ""
+}
+
+// paint fills the node background with the color matching its health.
+func paint(n *diagram.Node, h health) {
+	if h.color() == "" {
+		return
+	}
+
+	n.Options.Attributes["style"] = "filled"
+	n.Options.Attributes["fillcolor"] = h.color()
+
+	if h == healthIdle {
+		n.Options.Font.Color = idleFontColor
+	}
+}
+
+// inactive tells if a workload is either scaled to zero or has no replica available.
+func (h health) inactive() bool {
+	return h == healthIdle || h == healthCritical
+}
+
+// inactiveWorkload is a workload without any running pod, that services are linked to directly.
+type inactiveWorkload struct {
+	node   *diagram.Node
+	labels map[string]string
+}
+
+// inactiveEdge styles the edges reaching workloads without any running pod.
+func inactiveEdge(o *diagram.EdgeOptions) {
+	o.Style = "dashed"
+	o.Color = idleFontColor
+}
+
+// badge appends an extra label line to a node. Badges are applied once the whole diagram is generated, as pod labels
+// are derived from the labels of their owners.
+func (d *Diagram) badge(n *diagram.Node, line string) {
+	d.badges[n] = append(d.badges[n], line)
+}
+
+// applyBadges adds the collected badges to the labels of their nodes, once.
+func (d *Diagram) applyBadges() {
+	for n, lines := range d.badges {
+		n.Label(n.Options.Label + "\\n" + strings.Join(lines, "\\n"))
+		n.Options.Height += badgeHeight * float64(len(lines))
+	}
+
+	d.badges = make(map[*diagram.Node][]string)
+}
+
+func replicasHealth(ready, desired int32) health {
+	switch {
+	case desired == 0:
+		return healthIdle
+	case ready >= desired:
+		return healthHealthy
+	case ready == 0:
+		return healthCritical
+	default:
+		return healthWarning
+	}
+}
+
+func replicasBadge(ready, desired int32, state string) string {
+	if desired == 0 {
+		return "scaled to zero"
+	}
+
+	return fmt.Sprintf("%d/%d %s", ready, desired, state)
+}
+
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
+
+func deploymentHealth(v appsv1.Deployment) (health, string) {
+	desired := desiredReplicas(v.Spec.Replicas)
+
+	return replicasHealth(v.Status.AvailableReplicas, desired),
+		replicasBadge(v.Status.AvailableReplicas, desired, "available")
+}
+
+func daemonSetHealth(v appsv1.DaemonSet) (health, string) {
+	return replicasHealth(v.Status.NumberAvailable, v.Status.DesiredNumberScheduled),
+		replicasBadge(v.Status.NumberAvailable, v.Status.DesiredNumberScheduled, "available")
+}
+
+func replicaSetHealth(v appsv1.ReplicaSet) (health, string) {
+	desired := desiredReplicas(v.Spec.Replicas)
+
+	return replicasHealth(v.Status.ReadyReplicas, desired), replicasBadge(v.Status.ReadyReplicas, desired, "ready")
+}
+
+func statefulSetHealth(v appsv1.StatefulSet) (health, string) {
+	desired := desiredReplicas(v.Spec.Replicas)
+
+	return replicasHealth(v.Status.ReadyReplicas, desired), replicasBadge(v.Status.ReadyReplicas, desired, "ready")
+}
+
+// podHealth summarizes the state of a pod the way kubectl get pods does, and returns its restart count.
+func podHealth(v corev1.Pod) (health, string, int32) {
+	var restarts int32
+
+	statuses := append(append([]corev1.ContainerStatus{}, v.Status.InitContainerStatuses...), v.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		restarts += s.RestartCount
+	}
+
+	if v.DeletionTimestamp != nil {
+		return healthWarning, "Terminating", restarts
+	}
+
+	if reason := containersFailure(statuses); reason != "" {
+		return healthCritical, reason, restarts
+	}
+
+	switch v.Status.Phase {
+	case corev1.PodSucceeded:
+		return healthCompleted, "Completed", restarts
+	case corev1.PodFailed:
+		if v.Status.Reason != "" {
+			return healthCritical, v.Status.Reason, restarts
+		}
+
+		return healthCritical, "Failed", restarts
+	case corev1.PodPending:
+		return healthPending, "Pending", restarts
+	case corev1.PodRunning:
+		if !podReady(v) {
+			return healthWarning, "Running, not ready", restarts
+		}
+
+		return healthHealthy, "Running", restarts
+	case corev1.PodUnknown:
+	}
+
+	return healthUnknown, string(v.Status.Phase), restarts
+}
+
+func containersFailure(statuses []corev1.ContainerStatus) string {
+	for _, s := range statuses {
+		if s.State.Waiting == nil {
+			continue
+		}
+
+		switch s.State.Waiting.Reason {
+		case "CrashLoopBackOff":
+			if s.LastTerminationState.Terminated != nil && s.LastTerminationState.Terminated.Reason == "OOMKilled" {
+				return "CrashLoopBackOff (OOMKilled)"
+			}
+
+			return s.State.Waiting.Reason
+		case "ImagePullBackOff", "ErrImagePull", "InvalidImageName", "CreateContainerConfigError":
+			return s.State.Waiting.Reason
+		}
+	}
+
+	for _, s := range statuses {
+		if s.State.Terminated != nil && s.State.Terminated.Reason == "OOMKilled" {
+			return "OOMKilled"
+		}
+	}
+
+	return ""
+}
+
+func podReady(v corev1.Pod) bool {
+	for _, c := range v.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func podBadge(status string, restarts int32) string {
+	switch restarts {
+	case 0:
+		return status
+	case 1:
+		return status + " (1 restart)"
+	default:
+		return fmt.Sprintf("%s (%d restarts)", status, restarts)
+	}
+}
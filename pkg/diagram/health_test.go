@@ -0,0 +1,153 @@
+package diagram
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodHealth(t *testing.T) {
+	waiting := func(reason string, restarts int32) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:         "app",
+			RestartCount: restarts,
+			State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+		}
+	}
+
+	oomKilled := waiting("CrashLoopBackOff", 4)
+	oomKilled.LastTerminationState.Terminated = &corev1.ContainerStateTerminated{Reason: "OOMKilled"}
+
+	ready := []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+
+	tests := []struct {
+		name   string
+		status corev1.PodStatus
+		color  string
+		badge  string
+	}{
+		{
+			name:   "running",
+			status: corev1.PodStatus{Phase: corev1.PodRunning, Conditions: ready},
+			color:  healthyColor,
+			badge:  "Running",
+		},
+		{
+			name:   "not-ready",
+			status: corev1.PodStatus{Phase: corev1.PodRunning},
+			color:  warningColor,
+			badge:  "Running, not ready",
+		},
+		{
+			name: "crash-loop",
+			status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{waiting("CrashLoopBackOff", 7)},
+			},
+			color: criticalColor,
+			badge: "CrashLoopBackOff (7 restarts)",
+		},
+		{
+			name: "image-pull",
+			status: corev1.PodStatus{
+				Phase:             corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{waiting("ImagePullBackOff", 0)},
+			},
+			color: criticalColor,
+			badge: "ImagePullBackOff",
+		},
+		{
+			name:   "oom-killed",
+			status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{oomKilled}},
+			color:  criticalColor,
+			badge:  "CrashLoopBackOff (OOMKilled) (4 restarts)",
+		},
+		{
+			name: "init-restarted",
+			status: corev1.PodStatus{
+				Phase:                 corev1.PodPending,
+				InitContainerStatuses: []corev1.ContainerStatus{{Name: "init", RestartCount: 1}},
+			},
+			color: pendingColor,
+			badge: "Pending (1 restart)",
+		},
+		{
+			name:   "completed",
+			status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+			color:  completedColor,
+			badge:  "Completed",
+		},
+		{
+			name:   "evicted",
+			status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+			color:  criticalColor,
+			badge:  "Evicted",
+		},
+	}
+
+	for _, test := range tests {
+		d := generateFromFakeCluster(t, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: test.name, Namespace: testNamespace},
+			Status:     test.status,
+		})
+
+		n := d.pods[test.name]
+		if got := n.Options.Attributes["fillcolor"]; got != test.color {
+			t.Errorf("pod %s is filled with %s, want %s", test.name, got, test.color)
+		}
+
+		if want := test.name + "\\n" + test.badge; n.Options.Label != want {
+			t.Errorf("pod %s is labeled %q, want %q", test.name, n.Options.Label, want)
+		}
+	}
+}
+
+func TestDeploymentHealth(t *testing.T) {
+	replicas := func(n int32) *int32 {
+		return &n
+	}
+
+	deployment := func(name string, desired *int32, available int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: desired},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: available},
+		}
+	}
+
+	d := generateFromFakeCluster(t,
+		deployment("healthy", replicas(3), 3),
+		deployment("degraded", replicas(3), 1),
+		deployment("down", nil, 0),
+	)
+
+	tests := []struct {
+		name  string
+		color string
+		badge string
+	}{
+		{name: "healthy", color: healthyColor, badge: "3/3 available"},
+		{name: "degraded", color: warningColor, badge: "1/3 available"},
+		{name: "down", color: criticalColor, badge: "0/1 available"},
+	}
+
+	for _, test := range tests {
+		n, ok := d.deployments[test.name]
+		if !ok {
+			t.Errorf("deployment %s is not drawn", test.name)
+
+			continue
+		}
+
+		if got := n.Options.Attributes["fillcolor"]; got != test.color {
+			t.Errorf("deployment %s is filled with %s, want %s", test.name, got, test.color)
+		}
+
+		if !strings.HasSuffix(n.Options.Label, "\\n"+test.badge) {
+			t.Errorf("deployment %s is labeled %q, want the badge %q", test.name, n.Options.Label, test.badge)
+		}
+	}
+}
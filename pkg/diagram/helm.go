@@ -0,0 +1,80 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	helmReleaseAnnotation = "meta.helm.sh/release-name"
+	instanceLabel         = "app.kubernetes.io/instance"
+)
+
+// GroupByHelmRelease draws the objects of each Helm release in their own group inside the namespace.
+func GroupByHelmRelease(enabled bool) Option {
+	return func(d *Diagram) {
+		d.groupByHelmRelease = enabled
+	}
+}
+
+// helmReleaseName returns the Helm release an object belongs to, from the annotation Helm sets on the objects it
+// manages, or from the recommended instance label.
+func helmReleaseName(meta metav1.ObjectMeta) string {
+	if release := meta.Annotations[helmReleaseAnnotation]; release != "" {
+		return release
+	}
+
+	return meta.Labels[instanceLabel]
+}
+
+// releaseLabel describes a release with its chart and revision when its metadata was discovered.
+func releaseLabel(name string, release discovery.HelmRelease, found bool) string {
+	lines := []string{"release: " + name}
+
+	if found {
+		chart := release.Chart + "-" + release.ChartVersion
+		if release.AppVersion != "" {
+			chart += " (app " + release.AppVersion + ")"
+		}
+
+		lines = append(lines, chart, fmt.Sprintf("revision %d, %s", release.Revision, release.Status))
+	}
+
+	return strings.Join(lines, "\\n")
+}
+
+// releaseGroup returns the group of the Helm release an object belongs to, inside its namespace group, or its
+// namespace group when it doesn't belong to any release or when not grouping by release.
+func (d *Diagram) releaseGroup(namespace string, meta metav1.ObjectMeta) *diagram.Group {
+	if !d.groupByHelmRelease {
+		return d.namespaceGroups[namespace]
+	}
+
+	name := helmReleaseName(meta)
+	if name == "" {
+		return d.namespaceGroups[namespace]
+	}
+
+	if g, ok := d.releaseGroups[name]; ok {
+		return g
+	}
+
+	log.Debug().Msgf("Generating helm release: %s", name)
+
+	release, found := d.helmReleases[name]
+
+	d.releaseGroups[name] = diagram.NewGroup(namespace+"-release-"+name, func(o *diagram.GroupOptions) {
+		o.Font = diagram.Font{
+			Size: d.style.GroupFontSize,
+		}
+		o.BackgroundColor = d.style.ReleaseColor
+	}).Label(releaseLabel(name, release, found))
+	d.namespaceGroups[namespace].Group(d.releaseGroups[name])
+
+	return d.releaseGroups[name]
+}
@@ -0,0 +1,75 @@
+package diagram
+
+import (
+	"testing"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHelmReleaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want string
+	}{
+		{
+			name: "annotation",
+			meta: metav1.ObjectMeta{
+				Annotations: map[string]string{helmReleaseAnnotation: "shop"},
+				Labels:      map[string]string{instanceLabel: "other"},
+			},
+			want: "shop",
+		},
+		{
+			name: "instance label",
+			meta: metav1.ObjectMeta{Labels: map[string]string{instanceLabel: "shop"}},
+			want: "shop",
+		},
+		{
+			name: "none",
+			meta: metav1.ObjectMeta{Labels: map[string]string{"app": "shop"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := helmReleaseName(tt.meta); got != tt.want {
+				t.Errorf("helmReleaseName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParentGroup(t *testing.T) {
+	d, err := NewDiagram(t.TempDir(), "k8s", "Kubernetes", GroupByHelmRelease(true))
+	if err != nil {
+		t.Fatalf("NewDiagram() error = %v", err)
+	}
+
+	d.namespaceGroups[testNamespace] = diagram.NewGroup(testNamespace)
+	d.helmReleases["shop"] = discovery.HelmRelease{
+		Name: "shop", Revision: 3, Status: "deployed", Chart: "web", ChartVersion: "1.1.0", AppVersion: "2.0",
+	}
+
+	release := metav1.ObjectMeta{Annotations: map[string]string{helmReleaseAnnotation: "shop"}}
+
+	g := d.parentGroup(testNamespace, release)
+	if g == d.namespaceGroups[testNamespace] {
+		t.Fatal("object of a release added to the namespace group")
+	}
+
+	if got := d.parentGroup(testNamespace, release); got != g {
+		t.Error("objects of the same release added to different groups")
+	}
+
+	want := "release: shop\\nweb-1.1.0 (app 2.0)\\nrevision 3, deployed"
+	if got := releaseLabel("shop", d.helmReleases["shop"], true); got != want {
+		t.Errorf("releaseLabel() = %q, want %q", got, want)
+	}
+
+	if got := d.parentGroup(testNamespace, metav1.ObjectMeta{}); got != d.namespaceGroups[testNamespace] {
+		t.Error("object without release not added to the namespace group")
+	}
+}
@@ -0,0 +1,129 @@
+package diagram
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// ingressRoutes collects the routes of an ingress leading to each backend, in order of appearance.
+type ingressRoutes struct {
+	backends []*diagram.Node
+	routes   map[*diagram.Node][]string
+}
+
+func newIngressRoutes() *ingressRoutes {
+	return &ingressRoutes{routes: make(map[*diagram.Node][]string)}
+}
+
+func (r *ingressRoutes) add(backend *diagram.Node, route string) {
+	if _, ok := r.routes[backend]; !ok {
+		r.backends = append(r.backends, backend)
+	}
+
+	r.routes[backend] = append(r.routes[backend], route)
+}
+
+func (r *ingressRoutes) label(backend *diagram.Node) string {
+	return strings.Join(r.routes[backend], "\\n")
+}
+
+// ingressClass returns the class of an ingress, falling back on the deprecated annotation.
+func ingressClass(ing networkingv1.Ingress) string {
+	if ing.Spec.IngressClassName != nil {
+		return *ing.Spec.IngressClassName
+	}
+
+	return ing.Annotations[discovery.IngressClassAnnotation]
+}
+
+// ingressBadges describes the class of an ingress and the secrets its TLS hosts are terminated with.
+func ingressBadges(ing networkingv1.Ingress) []string {
+	var badges []string
+
+	if class := ingressClass(ing); class != "" {
+		badges = append(badges, "class: "+class)
+	}
+
+	for _, tls := range ing.Spec.TLS {
+		hosts := strings.Join(tls.Hosts, ",")
+		if hosts == "" {
+			hosts = "*"
+		}
+
+		secret := tls.SecretName
+		if secret == "" {
+			secret = "default certificate"
+		}
+
+		badges = append(badges, "TLS "+hosts+" ("+secret+")")
+	}
+
+	return badges
+}
+
+// ingressTLSHosts lists the hosts for which an ingress terminates TLS.
+func ingressTLSHosts(ing networkingv1.Ingress) map[string]bool {
+	hosts := make(map[string]bool)
+
+	for _, tls := range ing.Spec.TLS {
+		for _, host := range tls.Hosts {
+			hosts[host] = true
+		}
+	}
+
+	return hosts
+}
+
+// hostLabel describes an ingress host as scheme://host.
+func hostLabel(host string, tls map[string]bool) string {
+	scheme := "http://"
+	if tls[host] {
+		scheme = "https://"
+	}
+
+	if host == "" {
+		host = "*"
+	}
+
+	return scheme + host
+}
+
+// routeLabel describes an ingress path as scheme://host/path (pathType) → port.
+func routeLabel(host string, path networkingv1.HTTPIngressPath, tls map[string]bool) string {
+	p := path.Path
+	if p == "" {
+		p = "/"
+	}
+
+	label := hostLabel(host, tls) + p
+
+	if path.PathType != nil {
+		label += " (" + string(*path.PathType) + ")"
+	}
+
+	if port := backendPort(path.Backend); port != "" {
+		label += " → " + port
+	}
+
+	return label
+}
+
+func backendPort(backend networkingv1.IngressBackend) string {
+	if backend.Service == nil {
+		return ""
+	}
+
+	if backend.Service.Port.Name != "" {
+		return backend.Service.Port.Name
+	}
+
+	if backend.Service.Port.Number != 0 {
+		return strconv.Itoa(int(backend.Service.Port.Number))
+	}
+
+	return ""
+}
@@ -0,0 +1,154 @@
+package diagram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIngressBadges(t *testing.T) {
+	nginx, traefik := "nginx", "traefik"
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		spec        networkingv1.IngressSpec
+		want        []string
+	}{
+		{
+			name: "class",
+			spec: networkingv1.IngressSpec{IngressClassName: &nginx},
+			want: []string{"class: nginx"},
+		},
+		{
+			name:        "class annotation",
+			annotations: map[string]string{discovery.IngressClassAnnotation: "traefik"},
+			want:        []string{"class: traefik"},
+		},
+		{
+			name:        "class over annotation",
+			annotations: map[string]string{discovery.IngressClassAnnotation: "nginx"},
+			spec:        networkingv1.IngressSpec{IngressClassName: &traefik},
+			want:        []string{"class: traefik"},
+		},
+		{
+			name: "TLS",
+			spec: networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"shop.example.com", "www.example.com"}, SecretName: "shop-tls"},
+				{Hosts: []string{"api.example.com"}},
+				{SecretName: "wildcard-tls"},
+			}},
+			want: []string{
+				"TLS shop.example.com,www.example.com (shop-tls)",
+				"TLS api.example.com (default certificate)",
+				"TLS * (wildcard-tls)",
+			},
+		},
+		{name: "none"},
+	}
+
+	for _, test := range tests {
+		ing := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}, Spec: test.spec}
+
+		if got := ingressBadges(ing); strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: ingressBadges() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRouteLabel(t *testing.T) {
+	prefix, exact := networkingv1.PathTypePrefix, networkingv1.PathTypeExact
+	tls := map[string]bool{"shop.example.com": true}
+
+	backend := func(name string, number int32) networkingv1.IngressBackend {
+		return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+			Name: "web",
+			Port: networkingv1.ServiceBackendPort{Name: name, Number: number},
+		}}
+	}
+
+	tests := []struct {
+		name string
+		host string
+		path networkingv1.HTTPIngressPath
+		want string
+	}{
+		{
+			name: "TLS host",
+			host: "shop.example.com",
+			path: networkingv1.HTTPIngressPath{Path: "/api", PathType: &exact, Backend: backend("", 8080)},
+			want: "https://shop.example.com/api (Exact) → 8080",
+		},
+		{
+			name: "named port",
+			host: "admin.example.com",
+			path: networkingv1.HTTPIngressPath{Path: "/", PathType: &prefix, Backend: backend("http", 0)},
+			want: "http://admin.example.com/ (Prefix) → http",
+		},
+		{
+			name: "any host",
+			path: networkingv1.HTTPIngressPath{Backend: backend("", 0)},
+			want: "http://*/",
+		},
+	}
+
+	for _, test := range tests {
+		if got := routeLabel(test.host, test.path, tls); got != test.want {
+			t.Errorf("%s: routeLabel() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGenerateIngressesLabels(t *testing.T) {
+	prefix := networkingv1.PathTypePrefix
+	class := "nginx"
+
+	web := &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Name: "http"}}
+
+	d, err := NewTempDiagram("k8s", "Kubernetes")
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, webService(), &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: testNamespace},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: &class,
+			TLS:              []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}, SecretName: "shop-tls"}},
+			DefaultBackend:   &networkingv1.IngressBackend{Service: web},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "shop.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{Path: "/", PathType: &prefix, Backend: networkingv1.IngressBackend{Service: web}},
+							{Path: "/cart", PathType: &prefix, Backend: networkingv1.IngressBackend{Service: web}},
+						},
+					}},
+				},
+				{Host: "legacy.example.com"},
+			},
+		},
+	}))
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{
+		// The routes to one backend share one edge.
+		`label="https://shop.example.com/ (Prefix) → http\nhttps://shop.example.com/cart (Prefix) → http"`,
+		`shop\nclass: nginx\nTLS shop.example.com (shop-tls)`,
+		`label="default → http\nhttp://legacy.example.com"`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT source doesn't contain %s:\n%s", want, dot.String())
+		}
+	}
+}
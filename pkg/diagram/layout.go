@@ -0,0 +1,105 @@
+package diagram
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+)
+
+// layers are the ranks of the kinds in layered diagrams, from the Internet to the pods.
+var layers = map[string]int{
+	"Internet":    0,
+	"Ingress":     1,
+	"Service":     2,
+	"Deployment":  3,
+	"ReplicaSet":  4,
+	"StatefulSet": 4,
+	"DaemonSet":   4,
+	"Pod":         5,
+}
+
+// Directions returns the Graphviz rank directions a diagram can be drawn in.
+func Directions() []string {
+	return []string{"TB", "LR", "BT", "RL"}
+}
+
+// EdgeRoutings returns the Graphviz routings the edges of a diagram can be drawn with.
+func EdgeRoutings() []string {
+	return []string{"curved", "ortho", "polyline", "spline", "line"}
+}
+
+// validate checks the layout of the style.
+func (s Style) validate() error {
+	if !contains(Directions(), s.Direction) {
+		return fmt.Errorf("unknown direction %q, want one of %s", s.Direction, strings.Join(Directions(), ", "))
+	}
+
+	if !contains(EdgeRoutings(), s.Splines) {
+		return fmt.Errorf("unknown edge routing %q, want one of %s", s.Splines, strings.Join(EdgeRoutings(), ", "))
+	}
+
+	return nil
+}
+
+// applyLayers pins the nodes of the Internet, ingresses, services, workloads and pods onto consecutive ranks, for
+// diagrams to read the same way across namespaces. Each layer has an invisible anchor, one rank after the anchor of
+// the previous layer, which the nodes of the layer hang from, and the edges between layers are stretched over the
+// layers they skip. Edges going back to previous layers don't constrain the ranks.
+func (d *Diagram) applyLayers() {
+	for _, e := range d.allEdges() {
+		from, okFrom := layers[d.graphNodes[d.keys[e.Start()]].Kind]
+		to, okTo := layers[d.graphNodes[d.keys[e.End()]].Kind]
+
+		switch {
+		case !okFrom || !okTo:
+		case to < from:
+			e.Options.Attributes["constraint"] = "false"
+		case to-from > 1:
+			e.Options.Attributes["minlen"] = strconv.Itoa(to - from)
+		}
+	}
+
+	anchors := make([]*diagram.Node, layers["Pod"]+1)
+
+	for i := range anchors {
+		anchors[i] = diagram.NewNode(func(o *diagram.NodeOptions) {
+			o.Attributes["style"] = "invis"
+			o.Attributes["width"] = "0"
+			o.Attributes["height"] = "0"
+		})
+		d.diag.Add(anchors[i])
+
+		if i > 0 {
+			d.diag.ConnectByID(anchors[i-1].ID(), anchors[i].ID(), invisibleEdge(1))
+		}
+	}
+
+	keys := make([]string, 0, len(d.keyNodes))
+	for key := range d.keyNodes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		layer, ok := layers[d.graphNodes[key].Kind]
+		if !ok {
+			continue
+		}
+
+		for _, n := range d.keyNodes[key] {
+			d.diag.ConnectByID(anchors[layer].ID(), n.ID(), invisibleEdge(0))
+		}
+	}
+}
+
+func invisibleEdge(minlen int) diagram.EdgeOption {
+	return func(o *diagram.EdgeOptions) {
+		o.Forward = false
+		o.Attributes["style"] = "invis"
+		o.Attributes["minlen"] = strconv.Itoa(minlen)
+	}
+}
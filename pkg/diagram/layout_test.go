@@ -0,0 +1,64 @@
+package diagram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStyleValidate(t *testing.T) {
+	tests := []struct {
+		style Style
+		ok    bool
+	}{
+		{style: Style{Direction: "LR", Splines: "ortho"}, ok: true},
+		{style: Style{Direction: "diagonal"}},
+		{style: Style{Splines: "zigzag"}},
+	}
+
+	for _, test := range tests {
+		_, err := NewDiagram(t.TempDir(), "k8s", "Kubernetes", WithStyle(test.style))
+		if (err == nil) != test.ok {
+			t.Errorf("NewDiagram(%+v) error = %v, want ok %t", test.style, err, test.ok)
+		}
+	}
+}
+
+func TestApplyLayers(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+
+	svc := webService()
+	svc.Spec.Selector = selector
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Labels: selector}}
+
+	d, err := NewTempDiagram("k8s", "Kubernetes", WithStyle(Style{Layered: Bool(true)}))
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, svc, pod))
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{"style=invis", "minlen=1"} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT source doesn't contain %s:\n%s", want, dot.String())
+		}
+	}
+
+	// The pods are linked to their services with reversed edges, which must leave the ranks to the anchors.
+	for _, e := range d.allEdges() {
+		if e.Start() == d.pods["web"].ID() && e.End() == d.services["web"].ID() &&
+			e.Options.Attributes["constraint"] != "false" {
+			t.Errorf("pod to service edge constrains the ranks: %+v", e.Options.Attributes)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package diagram
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+)
+
+const (
+	legendNodeHeight = 0.4
+	legendPointSize  = 0.05
+	footerTimeFormat = "2006-01-02 15:04 MST"
+)
+
+// ShowLegend draws a legend of the icons, colors and edge styles the diagram uses, and a footer telling the cluster,
+// server version and namespace it was drawn from, when, and the filters applied, for the diagram to stay readable
+// once pasted away from its context.
+func ShowLegend(show bool) Option {
+	return func(d *Diagram) {
+		d.showLegend = show
+	}
+}
+
+// WithCluster sets the name of the cluster told by the footer of the legend.
+func WithCluster(name string) Option {
+	return func(d *Diagram) {
+		d.cluster = name
+	}
+}
+
+// legendColor is a node background explained by the legend, when a node of the diagram has it.
+type legendColor struct {
+	label string
+	color string
+}
+
+var legendColors = []legendColor{
+	{label: "Healthy", color: healthyColor},
+	{label: "Pending", color: pendingColor},
+	{label: "Degraded", color: warningColor},
+	{label: "Failing", color: criticalColor},
+	{label: "Completed", color: completedColor},
+	{label: "Scaled to zero", color: idleColor},
+	{label: "Added", color: changeFillColors[ChangeAdded]},
+	{label: "Removed", color: changeFillColors[ChangeRemoved]},
+	{label: "Changed", color: changeFillColors[ChangeChanged]},
+}
+
+// legendEdge is an edge style explained by the legend, when an edge of the diagram is drawn with it. The same colors
+// have different meanings in different modes, drawn tells them apart.
+type legendEdge struct {
+	label string
+	style diagram.EdgeOption
+	drawn func(d *Diagram, e *diagram.Edge) bool
+}
+
+var legendEdges = []legendEdge{
+	{
+		label: "Not ready",
+		style: notReadyEdge,
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return e.Options.Color == notReadyColor
+		},
+	},
+	{
+		label: "No running pod",
+		style: inactiveEdge,
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return e.Options.Color == idleFontColor && e.Options.Style == "dashed" &&
+				contains(workloadKinds, d.kind(e.Start()))
+		},
+	},
+	{
+		label: "Default backend",
+		style: func(o *diagram.EdgeOptions) {
+			o.Style = "dashed"
+		},
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return e.Options.Style == "dashed" && d.kind(e.Start()) == "Ingress"
+		},
+	},
+	{
+		label: "Permissions",
+		style: rbacEdge,
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return e.Options.Color == rbacColor
+		},
+	},
+	{
+		label: "Observed calls",
+		style: callEdge,
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return e.Options.Color == callColor
+		},
+	},
+	{
+		label: "Exposed to the Internet",
+		style: coloredEdge(securityColor),
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return d.showSecurity && d.kind(e.Start()) == "Internet" && e.Options.Color == securityColor
+		},
+	},
+	{
+		label: "Over 5% errors",
+		style: coloredEdge(trafficErrorColor),
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return !d.highlighted && d.kind(e.Start()) != "Internet" && e.Options.Color == trafficErrorColor
+		},
+	},
+	changeLegendEdge("Added", ChangeAdded),
+	changeLegendEdge("Removed", ChangeRemoved),
+	changeLegendEdge("Changed", ChangeChanged),
+}
+
+var workloadKinds = []string{"Deployment", "DaemonSet", "ReplicaSet", "StatefulSet"}
+
+func changeLegendEdge(label string, c Change) legendEdge {
+	return legendEdge{
+		label: label,
+		style: coloredEdge(changeEdgeColors[c]),
+		drawn: func(d *Diagram, e *diagram.Edge) bool {
+			return d.highlighted && e.Options.Color == changeEdgeColors[c]
+		},
+	}
+}
+
+func coloredEdge(color string) diagram.EdgeOption {
+	return func(o *diagram.EdgeOptions) {
+		o.Color = color
+	}
+}
+
+// kind returns the kind of the object a node draws.
+func (d *Diagram) kind(id string) string {
+	return d.graphNodes[d.keys[id]].Kind
+}
+
+// applyLegend draws the legend in its own group, from the nodes and edges drawn so far: a row of the icons of the
+// kinds, a row of the backgrounds, and a row of the edge styles.
+func (d *Diagram) applyLegend() {
+	g := diagram.NewGroup("legend", func(o *diagram.GroupOptions) {
+		o.Font = diagram.Font{
+			Size: d.style.GroupFontSize,
+		}
+		o.BackgroundColor = d.style.Background
+		o.Style = "rounded,dashed"
+	}).Label("Legend")
+
+	icons, colors := d.legendIcons(), d.legendColors()
+	g.Add(icons...).Add(colors...)
+
+	var rows [][]*diagram.Node
+
+	for _, row := range [][]*diagram.Node{icons, colors, d.legendEdges(g)} {
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 {
+		return
+	}
+
+	// The rows are stacked on consecutive ranks by invisible edges between their first nodes.
+	for i := 1; i < len(rows); i++ {
+		g.ConnectByID(rows[i-1][0].ID(), rows[i][0].ID(), invisibleEdge(1))
+	}
+
+	d.diag.Group(g)
+}
+
+// legendIcons returns a node per kind drawn with an icon, with the icon and the style of the kind.
+func (d *Diagram) legendIcons() []*diagram.Node {
+	keys := make([]string, 0, len(d.keyNodes))
+	for key := range d.keyNodes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var nodes []*diagram.Node
+
+	kinds := make(map[string]bool)
+
+	for _, key := range keys {
+		kind := d.graphNodes[key].Kind
+		if kinds[kind] {
+			continue
+		}
+
+		for _, n := range d.keyNodes[key] {
+			style := d.style.Kinds[kind]
+			if n.Options.Image == "" && style.Icon == "" {
+				continue
+			}
+
+			image := n.Options.Image
+			icon := diagram.NewNode(
+				diagram.NodeLabel(kind),
+				diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+				diagram.Width(d.style.NodeWidth),
+				func(o *diagram.NodeOptions) {
+					o.Image = image
+				},
+			)
+			d.styleNode(icon, style)
+
+			nodes = append(nodes, icon)
+			kinds[kind] = true
+
+			break
+		}
+	}
+
+	return nodes
+}
+
+// legendColors returns a node per background of the nodes of the diagram, and one for the outline of the risky or
+// exposed nodes.
+func (d *Diagram) legendColors() []*diagram.Node {
+	fills, outlined := make(map[string]bool), false
+
+	for _, nodes := range d.keyNodes {
+		for _, n := range nodes {
+			fills[n.Options.Attributes["fillcolor"]] = true
+			outlined = outlined || n.Options.Attributes["color"] == securityColor
+		}
+	}
+
+	var nodes []*diagram.Node
+
+	for _, c := range legendColors {
+		if !fills[c.color] {
+			continue
+		}
+
+		color := c.color
+		nodes = append(nodes, d.legendBox(c.label, func(o *diagram.NodeOptions) {
+			o.Style = "rounded,filled"
+			o.Attributes["fillcolor"] = color
+		}))
+	}
+
+	if outlined {
+		nodes = append(nodes, d.legendBox("Risky or exposed", func(o *diagram.NodeOptions) {
+			o.Attributes["color"] = securityColor
+			o.Attributes["penwidth"] = securityPenWidth
+		}))
+	}
+
+	return nodes
+}
+
+func (d *Diagram) legendBox(label string, opts ...diagram.NodeOption) *diagram.Node {
+	return diagram.NewNode(append([]diagram.NodeOption{
+		diagram.NodeLabel(label),
+		diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+		diagram.Width(d.style.NodeWidth),
+		func(o *diagram.NodeOptions) {
+			o.Height = legendNodeHeight
+			o.LabelLocation = "c"
+		},
+	}, opts...)...)
+}
+
+// legendEdges draws an edge per style of the edges of the diagram, between two points of the legend group, and
+// returns their start points.
+func (d *Diagram) legendEdges(g *diagram.Group) []*diagram.Node {
+	edges := d.allEdges()
+
+	var starts []*diagram.Node
+
+	for _, l := range legendEdges {
+		for _, e := range edges {
+			if !l.drawn(d, e) {
+				continue
+			}
+
+			start, end := legendPoint(), legendPoint()
+			g.Connect(start, end, l.style, edgeLabel(l.label))
+			starts = append(starts, start)
+
+			break
+		}
+	}
+
+	return starts
+}
+
+func legendPoint() *diagram.Node {
+	return diagram.NewNode(func(o *diagram.NodeOptions) {
+		o.Shape = "point"
+		o.Style = ""
+		o.Width = legendPointSize
+		o.Height = legendPointSize
+	})
+}
+
+// footer returns the lines of the footer: the cluster, server version and namespace the diagram was drawn from, when,
+// and the filters applied.
+func (d *Diagram) footer() []string {
+	var source, lines []string
+
+	if d.cluster != "" {
+		source = append(source, "cluster "+d.cluster)
+	}
+
+	if d.version != "" {
+		source = append(source, "Kubernetes "+d.version)
+	}
+
+	if d.namespace != "" {
+		source = append(source, "namespace "+d.namespace)
+	}
+
+	if len(source) > 0 {
+		lines = append(lines, strings.Join(source, ", "))
+	}
+
+	if !d.generatedAt.IsZero() {
+		lines = append(lines, "generated "+d.generatedAt.Format(footerTimeFormat))
+	}
+
+	for _, r := range d.include {
+		lines = append(lines, "include "+r.String())
+	}
+
+	for _, r := range d.exclude {
+		lines = append(lines, "exclude "+r.String())
+	}
+
+	return lines
+}
+
+// labelFooter sets the label of the root graph to the label of the diagram followed by the footer, drawn under the
+// diagram. The label of the root graph is set when it is created, before the objects it draws are known: its nodes,
+// edges and groups are moved to a new root graph.
+func (d *Diagram) labelFooter() error {
+	g, err := d.newGraph(strings.Join(append([]string{d.label}, d.footer()...), `\n`))
+	if err != nil {
+		return err
+	}
+
+	g.Add(d.diag.Nodes()...)
+
+	for _, e := range d.diag.Edges() {
+		options := e.Options
+		g.ConnectByID(e.Start(), e.End(), func(o *diagram.EdgeOptions) {
+			*o = options
+		})
+	}
+
+	for _, group := range d.diag.Groups() {
+		g.Group(group)
+	}
+
+	d.diag = g
+
+	return nil
+}
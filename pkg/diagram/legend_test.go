@@ -0,0 +1,99 @@
+package diagram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShowLegend(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+
+	svc := webService()
+	svc.Spec.Selector = selector
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Labels: selector},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+
+	d, err := NewTempDiagram("k8s", "Kubernetes",
+		ShowLegend(true),
+		WithCluster("prod"),
+		WithFilter(nil, []discovery.Rule{{Kinds: []string{"Secret"}}}),
+	)
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, svc, pod))
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"label=Legend",
+		"label=Pod",
+		"label=Service",
+		"label=Pending",
+		`label="Not ready"`,
+		`label="Kubernetes\ncluster prod, Kubernetes v1.21.0, namespace app\ngenerated `,
+		`\nexclude kinds Secret";`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT source doesn't contain %s:\n%s", want, dot.String())
+		}
+	}
+
+	if strings.Contains(dot.String(), "label=Healthy") {
+		t.Errorf("legend explains a background the diagram doesn't use:\n%s", dot.String())
+	}
+}
+
+func TestLabelFooter(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+
+	svc := webService()
+	svc.Spec.Selector = selector
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Labels: selector},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	d, err := NewTempDiagram("k8s", `My "app"`, ShowLegend(true))
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, svc, pod))
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`label="My &#34;app&#34;\nKubernetes v1.21.0, namespace app\ngenerated `,
+		"subgraph cluster_app {",
+		"subgraph cluster_legend {",
+		`label=web\nClusterIP`,
+		`label="Not ready"`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT source doesn't contain %s:\n%s", want, dot.String())
+		}
+	}
+
+	if !strings.Contains(dot.String(), "->") {
+		t.Errorf("DOT source lost the edges of the diagram:\n%s", dot.String())
+	}
+}
@@ -0,0 +1,68 @@
+package diagram
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// mermaidEscaper escapes the characters ending Mermaid labels.
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "|", "#124;")
+
+// WriteMermaid writes the objects and links of the diagram as a Mermaid flowchart, the objects of each namespace in a
+// subgraph.
+func (d *Diagram) WriteMermaid(w io.Writer) error {
+	g := d.Graph()
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintln(b, "flowchart TB")
+
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.Key] = fmt.Sprintf("n%d", i)
+	}
+
+	nodes := append([]GraphNode(nil), g.Nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Namespace < nodes[j].Namespace
+	})
+
+	namespace, subgraphs := "", 0
+
+	for _, n := range nodes {
+		if n.Namespace != namespace {
+			if namespace != "" {
+				fmt.Fprintln(b, "  end")
+			}
+
+			namespace = n.Namespace
+			subgraphs++
+
+			fmt.Fprintf(b, "  subgraph ns%d[\"%s\"]\n", subgraphs, mermaidEscaper.Replace(namespace))
+		}
+
+		fmt.Fprintf(b, "    %s[\"%s<br/>%s\"]\n", ids[n.Key], n.Kind, mermaidEscaper.Replace(n.Name))
+	}
+
+	if namespace != "" {
+		fmt.Fprintln(b, "  end")
+	}
+
+	for _, e := range g.Edges {
+		if e.Label == "" {
+			fmt.Fprintf(b, "  %s --> %s\n", ids[e.From], ids[e.To])
+
+			continue
+		}
+
+		fmt.Fprintf(b, "  %s -->|\"%s\"| %s\n", ids[e.From], mermaidEscaper.Replace(e.Label), ids[e.To])
+	}
+
+	if err := b.Flush(); err != nil {
+		return fmt.Errorf("writing diagram: %w", err)
+	}
+
+	return nil
+}
@@ -8,19 +8,29 @@ import (
 	"github.com/blushft/go-diagrams/nodes/apps"
 	"github.com/blushft/go-diagrams/nodes/k8s"
 	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
 	kindDeployment = "deployment"
-	setColor       = "#9EBCDA"
+	// revisionAnnotation numbers the rollouts of a deployment, on the deployment and on the replicaSet of each rollout.
+	revisionAnnotation = "deployment.kubernetes.io/revision"
 )
 
 func (d *Diagram) GenerateDeployments(namespace string, o *appsv1.DeploymentList) {
 	for _, v := range o.Items {
-		if v.Namespace != namespace || v.Status.Replicas == 0 || v.Status.AvailableReplicas == 0 {
+		if v.Namespace == namespace {
+			d.rollouts[v.Name] = v
+		}
+
+		h, badge := deploymentHealth(v)
+		if v.Namespace != namespace || (h == healthIdle && !d.showScaledToZero) {
 			continue
 		}
 
@@ -28,16 +38,28 @@ func (d *Diagram) GenerateDeployments(namespace string, o *appsv1.DeploymentList
 
 		d.deployments[v.Name] = k8s.Compute.Deploy(
 			diagram.NodeLabel(v.Name),
-			diagram.SetFontOptions(diagram.Font{Size: nodeFontSize}),
-			diagram.Width(nodeWidth),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
 		)
-		d.namespaceGroups[namespace].Add(d.deployments[v.Name])
+		d.parentGroup(namespace, v.ObjectMeta).Add(d.deployments[v.Name])
+		d.register(
+			d.deployments[v.Name], "Deployment", namespace, v.Name, replicasAttributes(v.Spec.Template, v.Spec.Replicas),
+		)
+		d.labelObject(d.deployments[v.Name], v.Labels)
+
+		paint(d.deployments[v.Name], h)
+		d.badge(d.deployments[v.Name], badge)
+
+		if h.inactive() {
+			d.inactiveWorkloads = append(d.inactiveWorkloads, inactiveWorkload{d.deployments[v.Name], v.Spec.Template.Labels})
+		}
 	}
 }
 
 func (d *Diagram) GenerateDaemonSets(namespace string, o *appsv1.DaemonSetList) {
 	for _, v := range o.Items {
-		if v.Namespace != namespace || v.Status.CurrentNumberScheduled == 0 {
+		h, badge := daemonSetHealth(v)
+		if v.Namespace != namespace || (h == healthIdle && !d.showScaledToZero) {
 			continue
 		}
 
@@ -45,23 +67,39 @@ func (d *Diagram) GenerateDaemonSets(namespace string, o *appsv1.DaemonSetList)
 
 		d.daemonSets[v.Name] = k8s.Compute.Ds(
 			diagram.NodeLabel(v.Name),
-			diagram.SetFontOptions(diagram.Font{Size: nodeFontSize}),
-			diagram.Width(nodeWidth),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
 		)
 		d.daemonSetGroups[v.Name] = diagram.NewGroup(v.Name, func(o *diagram.GroupOptions) {
 			o.Font = diagram.Font{
-				Size: groupFontSize,
+				Size: d.style.GroupFontSize,
 			}
-			o.BackgroundColor = setColor
+			o.BackgroundColor = d.style.SetColor
 		}).Label("ds")
-		d.namespaceGroups[namespace].Group(d.daemonSetGroups[v.Name])
-		d.namespaceGroups[namespace].Add(d.daemonSets[v.Name])
+		d.parentGroup(namespace, v.ObjectMeta).Add(d.daemonSets[v.Name]).Group(d.daemonSetGroups[v.Name])
+		d.register(d.daemonSets[v.Name], "DaemonSet", namespace, v.Name, imagesAttributes(v.Spec.Template))
+		d.labelObject(d.daemonSets[v.Name], v.Labels)
+
+		paint(d.daemonSets[v.Name], h)
+		d.badge(d.daemonSets[v.Name], badge)
+
+		if h.inactive() {
+			d.inactiveWorkloads = append(d.inactiveWorkloads, inactiveWorkload{d.daemonSets[v.Name], v.Spec.Template.Labels})
+		}
 	}
 }
 
 func (d *Diagram) GenerateReplicaSets(namespace string, o *appsv1.ReplicaSetList) {
 	for _, v := range o.Items {
-		if v.Namespace != namespace || v.Status.Replicas == 0 {
+		if v.Namespace != namespace {
+			continue
+		}
+
+		h, badge := replicaSetHealth(v)
+		owned := ownedByDeployment(v)
+		old := owned && d.oldReplicaSet(v)
+
+		if h == healthIdle && ((old && !d.showOldReplicaSets) || (!old && !d.showScaledToZero)) {
 			continue
 		}
 
@@ -69,16 +107,25 @@ func (d *Diagram) GenerateReplicaSets(namespace string, o *appsv1.ReplicaSetList
 
 		d.replicaSets[v.Name] = k8s.Compute.Rs(
 			diagram.NodeLabel(v.Name),
-			diagram.SetFontOptions(diagram.Font{Size: nodeFontSize}),
-			diagram.Width(nodeWidth),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
 		)
 		d.replicaSetGroups[v.Name] = diagram.NewGroup(v.Name, func(o *diagram.GroupOptions) {
 			o.Font = diagram.Font{
-				Size: groupFontSize,
+				Size: d.style.GroupFontSize,
 			}
-			o.BackgroundColor = setColor
+			o.BackgroundColor = d.style.SetColor
 		}).Label("rs")
-		d.namespaceGroups[namespace].Add(d.replicaSets[v.Name]).Group(d.replicaSetGroups[v.Name])
+		d.parentGroup(namespace, v.ObjectMeta).Add(d.replicaSets[v.Name]).Group(d.replicaSetGroups[v.Name])
+		d.register(d.replicaSets[v.Name], "ReplicaSet", namespace, discovery.StableName(&v.ObjectMeta), nil)
+		d.labelObject(d.replicaSets[v.Name], v.Labels)
+
+		paint(d.replicaSets[v.Name], h)
+		d.badge(d.replicaSets[v.Name], badge)
+
+		if h.inactive() && !owned {
+			d.inactiveWorkloads = append(d.inactiveWorkloads, inactiveWorkload{d.replicaSets[v.Name], v.Spec.Template.Labels})
+		}
 
 		for _, o := range v.GetOwnerReferences() {
 			if strings.ToLower(o.Kind) != kindDeployment {
@@ -95,9 +142,50 @@ func (d *Diagram) GenerateReplicaSets(namespace string, o *appsv1.ReplicaSetList
 	}
 }
 
+func ownedByDeployment(v appsv1.ReplicaSet) bool {
+	for _, o := range v.GetOwnerReferences() {
+		if strings.ToLower(o.Kind) == kindDeployment {
+			return true
+		}
+	}
+
+	return false
+}
+
+// oldReplicaSet tells if a replicaSet owned by a deployment was left behind by one of its previous rollouts, rather
+// than running its current revision.
+func (d *Diagram) oldReplicaSet(v appsv1.ReplicaSet) bool {
+	for _, o := range v.GetOwnerReferences() {
+		if strings.ToLower(o.Kind) != kindDeployment {
+			continue
+		}
+
+		deployment, ok := d.rollouts[o.Name]
+
+		return !ok || !currentReplicaSet(deployment, v)
+	}
+
+	return false
+}
+
+// currentReplicaSet tells if a replicaSet runs the current revision of a deployment: the one annotated with the same
+// revision, or, when the deployment isn't annotated, the one whose pod template only differs from the deployment's by
+// its pod-template-hash label.
+func currentReplicaSet(deployment appsv1.Deployment, v appsv1.ReplicaSet) bool {
+	if revision, ok := deployment.Annotations[revisionAnnotation]; ok {
+		return v.Annotations[revisionAnnotation] == revision
+	}
+
+	template := v.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+
+	return equality.Semantic.DeepEqual(*template, deployment.Spec.Template)
+}
+
 func (d *Diagram) GenerateStatefulSets(namespace string, o *appsv1.StatefulSetList) {
 	for _, v := range o.Items {
-		if v.Namespace != namespace || v.Status.Replicas == 0 {
+		h, badge := statefulSetHealth(v)
+		if v.Namespace != namespace || (h == healthIdle && !d.showScaledToZero) {
 			continue
 		}
 
@@ -105,17 +193,27 @@ func (d *Diagram) GenerateStatefulSets(namespace string, o *appsv1.StatefulSetLi
 
 		d.statefulSets[v.Name] = k8s.Compute.Sts(
 			diagram.NodeLabel(v.Name),
-			diagram.SetFontOptions(diagram.Font{Size: nodeFontSize}),
-			diagram.Width(nodeWidth),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
 		)
 		d.statefulSetGroups[v.Name] = diagram.NewGroup(v.Name, func(o *diagram.GroupOptions) {
 			o.Font = diagram.Font{
-				Size: groupFontSize,
+				Size: d.style.GroupFontSize,
 			}
-			o.BackgroundColor = setColor
+			o.BackgroundColor = d.style.SetColor
 		}).Label("sts")
-		d.namespaceGroups[namespace].Group(d.statefulSetGroups[v.Name])
-		d.namespaceGroups[namespace].Add(d.statefulSets[v.Name])
+		d.parentGroup(namespace, v.ObjectMeta).Add(d.statefulSets[v.Name]).Group(d.statefulSetGroups[v.Name])
+		d.register(
+			d.statefulSets[v.Name], "StatefulSet", namespace, v.Name, replicasAttributes(v.Spec.Template, v.Spec.Replicas),
+		)
+		d.labelObject(d.statefulSets[v.Name], v.Labels)
+
+		paint(d.statefulSets[v.Name], h)
+		d.badge(d.statefulSets[v.Name], badge)
+
+		if h.inactive() {
+			d.inactiveWorkloads = append(d.inactiveWorkloads, inactiveWorkload{d.statefulSets[v.Name], v.Spec.Template.Labels})
+		}
 	}
 }
 
@@ -168,9 +266,22 @@ func (d *Diagram) GeneratePods(namespace string, o *corev1.PodList) {
 
 		d.pods[v.Name] = k8s.Compute.Pod(
 			diagram.NodeLabel(v.Name),
-			diagram.SetFontOptions(diagram.Font{Size: nodeFontSize}),
-			diagram.Width(nodeWidth),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
 		)
+		d.register(d.pods[v.Name], "Pod", namespace, discovery.StableName(&v.ObjectMeta), nil)
+		d.labelObject(d.pods[v.Name], v.Labels)
+		d.podLabels[v.Name] = v.Labels
+		d.podServiceAccounts[v.Name] = v.Spec.ServiceAccountName
+
+		if v.Spec.ServiceAccountName == "" {
+			d.podServiceAccounts[v.Name] = "default"
+		}
+
+		h, status, restarts := podHealth(v)
+		paint(d.pods[v.Name], h)
+		d.badge(d.pods[v.Name], podBadge(status, restarts))
+		d.GenerateContainers(d.pods[v.Name], v)
 
 		if len(v.GetOwnerReferences()) > 0 {
 			for _, o := range v.GetOwnerReferences() {
@@ -185,42 +296,78 @@ func (d *Diagram) GeneratePods(namespace string, o *corev1.PodList) {
 				}
 			}
 		} else {
-			d.namespaceGroups[namespace].Add(d.pods[v.Name])
+			d.parentGroup(namespace, v.ObjectMeta).Add(d.pods[v.Name])
 		}
 	}
 }
 
-func (d *Diagram) GenerateLinksFromServiceToPods(namespace string, service string, endpoints *corev1.EndpointsList) {
-	for _, ep := range endpoints.Items {
-		if ep.Namespace != namespace {
+// GenerateLinksFromServiceToPods links a service to the pods backing it, and tells if any was found. The pods listed
+// as not ready by its endpoints, or selected by the service without being listed at all, are linked with a dashed edge.
+func (d *Diagram) GenerateLinksFromServiceToPods(namespace string, svc corev1.Service, backends []serviceBackend) bool {
+	linked := make(map[string]bool)
+
+	for _, b := range backends {
+		if _, ok := d.pods[b.pod]; !ok {
 			continue
 		}
 
-		if service != ep.Name {
+		opts := []diagram.EdgeOption{diagram.Reverse(), edgeLabel(b.ports)}
+		if !b.ready {
+			opts = append(opts, notReadyEdge)
+		}
+
+		d.namespaceGroups[namespace].Connect(d.pods[b.pod], d.services[svc.Name], opts...)
+
+		linked[b.pod] = true
+	}
+
+	for _, pod := range d.selectedPods(svc) {
+		if linked[pod] {
 			continue
 		}
 
-		for _, subset := range ep.Subsets {
-			for _, address := range subset.Addresses {
-				if address.TargetRef == nil {
-					continue
-				}
+		d.namespaceGroups[namespace].Connect(d.pods[pod], d.services[svc.Name], diagram.Reverse(), notReadyEdge)
 
-				if strings.ToLower(address.TargetRef.Kind) != "pod" {
-					continue
-				}
+		linked[pod] = true
+	}
 
-				if _, ok := d.pods[address.TargetRef.Name]; !ok {
-					continue
-				}
+	return len(linked) > 0
+}
 
-				d.namespaceGroups[namespace].Connect(d.pods[address.TargetRef.Name], d.services[service], diagram.Reverse())
-			}
+// GenerateLinksFromServiceToInactiveWorkloads links a service without any pod to the workloads it selects that are
+// scaled to zero or have no replica available.
+func (d *Diagram) GenerateLinksFromServiceToInactiveWorkloads(namespace string, svc corev1.Service) {
+	if len(svc.Spec.Selector) == 0 {
+		return
+	}
+
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	for _, w := range d.inactiveWorkloads {
+		if !selector.Matches(labels.Set(w.labels)) {
+			continue
 		}
+
+		d.namespaceGroups[namespace].Connect(w.node, d.services[svc.Name], diagram.Reverse(), inactiveEdge)
+	}
+}
+
+// GenerateInternet draws the node load balancers are reached from.
+func (d *Diagram) GenerateInternet() *diagram.Node {
+	if d.internet == nil {
+		d.internet = apps.Network.Internet(diagram.NodeLabel("Internet"))
+		d.register(d.internet, "Internet", "", "Internet", nil)
 	}
+
+	return d.internet
 }
 
-func (d *Diagram) GenerateServices(namespace string, services *corev1.ServiceList, endpoints *corev1.EndpointsList) {
+func (d *Diagram) GenerateServices(
+	namespace string,
+	services *corev1.ServiceList,
+	endpoints *corev1.EndpointsList,
+	endpointSlices *discoveryv1.EndpointSliceList,
+) {
 	for _, svc := range services.Items {
 		if svc.Namespace != namespace {
 			continue
@@ -230,17 +377,24 @@ func (d *Diagram) GenerateServices(namespace string, services *corev1.ServiceLis
 
 		d.services[svc.Name] = k8s.Network.Svc(
 			diagram.NodeLabel(svc.Name),
-			diagram.SetFontOptions(diagram.Font{Size: nodeFontSize}),
-			diagram.Width(nodeWidth),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
 		)
-		d.namespaceGroups[namespace].Add(d.services[svc.Name])
+		d.parentGroup(namespace, svc.ObjectMeta).Add(d.services[svc.Name])
+		d.register(d.services[svc.Name], "Service", namespace, svc.Name, serviceAttributes(svc))
+		d.labelObject(d.services[svc.Name], svc.Labels)
+		d.badge(d.services[svc.Name], serviceTypeBadge(svc))
 
-		d.GenerateLinksFromServiceToPods(namespace, svc.Name, endpoints)
+		for _, badge := range nodePortBadges(svc) {
+			d.badge(d.services[svc.Name], badge)
+		}
+
+		if !d.GenerateLinksFromServiceToPods(namespace, svc, serviceBackends(svc, endpoints, endpointSlices)) {
+			d.GenerateLinksFromServiceToInactiveWorkloads(namespace, svc)
+		}
 
 		for _, lb := range svc.Status.LoadBalancer.Ingress {
-			if d.internet == nil {
-				d.internet = apps.Network.Internet(diagram.NodeLabel("Internet"))
-			}
+			d.GenerateInternet()
 
 			if lb.IP != "" {
 				d.diag.Connect(
@@ -267,6 +421,72 @@ func (d *Diagram) GenerateServices(namespace string, services *corev1.ServiceLis
 	}
 }
 
+// GenerateIngressBackend returns the node an ingress backend points to: either a service, or the object referenced by
+// a resource backend. It returns nil when the backend service is not drawn.
+func (d *Diagram) GenerateIngressBackend(namespace string, backend networkingv1.IngressBackend) *diagram.Node {
+	switch {
+	case backend.Service != nil:
+		return d.services[backend.Service.Name]
+	case backend.Resource != nil:
+		return d.GenerateResourceBackend(namespace, *backend.Resource)
+	default:
+		return nil
+	}
+}
+
+// GenerateResourceBackend draws the object referenced by an ingress resource backend, such as a storage bucket.
+func (d *Diagram) GenerateResourceBackend(namespace string, ref corev1.TypedLocalObjectReference) *diagram.Node {
+	kind := ref.Kind
+	if ref.APIGroup != nil && *ref.APIGroup != "" {
+		kind += "." + *ref.APIGroup
+	}
+
+	key := kind + "/" + ref.Name
+	if n, ok := d.resources[key]; ok {
+		return n
+	}
+
+	log.Debug().Msgf("Generating resource backend: %s", key)
+
+	d.resources[key] = k8s.Others.Crd(
+		diagram.NodeLabel(ref.Name),
+		diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+		diagram.Width(d.style.NodeWidth),
+	)
+	d.namespaceGroups[namespace].Add(d.resources[key])
+	d.register(d.resources[key], kind, namespace, ref.Name, nil)
+	d.badge(d.resources[key], kind)
+
+	return d.resources[key]
+}
+
+// GenerateIngressDefaultBackend links an ingress to the backend receiving the requests matching none of its rules,
+// including the ones for the hosts of its rules without any path.
+func (d *Diagram) GenerateIngressDefaultBackend(namespace string, ing networkingv1.Ingress, hosts []string) {
+	if ing.Spec.DefaultBackend == nil {
+		return
+	}
+
+	n := d.GenerateIngressBackend(namespace, *ing.Spec.DefaultBackend)
+	if n == nil {
+		return
+	}
+
+	label := "default"
+	if port := backendPort(*ing.Spec.DefaultBackend); port != "" {
+		label += " → " + port
+	}
+
+	d.namespaceGroups[namespace].Connect(
+		d.ingresses[ing.Name],
+		n,
+		edgeLabel(strings.Join(append([]string{label}, hosts...), "\\n")),
+		func(o *diagram.EdgeOptions) {
+			o.Style = "dashed"
+		},
+	)
+}
+
 func (d *Diagram) GenerateIngresses(namespace string, o *networkingv1.IngressList) {
 	for _, ing := range o.Items {
 		if ing.Namespace != namespace {
@@ -277,29 +497,44 @@ func (d *Diagram) GenerateIngresses(namespace string, o *networkingv1.IngressLis
 
 		d.ingresses[ing.Name] = k8s.Network.Ing(
 			diagram.NodeLabel(ing.Name),
-			diagram.SetFontOptions(diagram.Font{Size: nodeFontSize}),
-			diagram.Width(nodeWidth),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
 		)
-		d.namespaceGroups[namespace].Add(d.ingresses[ing.Name])
+		d.parentGroup(namespace, ing.ObjectMeta).Add(d.ingresses[ing.Name])
+		d.register(d.ingresses[ing.Name], "Ingress", namespace, ing.Name, nil)
+		d.labelObject(d.ingresses[ing.Name], ing.Labels)
+
+		for _, badge := range ingressBadges(ing) {
+			d.badge(d.ingresses[ing.Name], badge)
+		}
+
+		tls := ingressTLSHosts(ing)
+		routes := newIngressRoutes()
+
+		var defaultHosts []string
 
 		for _, rule := range ing.Spec.Rules {
-			if rule.HTTP.Paths == nil {
+			if rule.HTTP == nil {
+				defaultHosts = append(defaultHosts, hostLabel(rule.Host, tls))
+
 				continue
 			}
 
 			for _, path := range rule.HTTP.Paths {
-				if _, ok := d.services[path.Backend.Service.Name]; !ok {
-					continue
+				if n := d.GenerateIngressBackend(namespace, path.Backend); n != nil {
+					routes.add(n, routeLabel(rule.Host, path, tls))
 				}
-
-				d.namespaceGroups[namespace].Connect(d.ingresses[ing.Name], d.services[path.Backend.Service.Name])
 			}
 		}
 
+		for _, n := range routes.backends {
+			d.namespaceGroups[namespace].Connect(d.ingresses[ing.Name], n, edgeLabel(routes.label(n)))
+		}
+
+		d.GenerateIngressDefaultBackend(namespace, ing, defaultHosts)
+
 		for _, lb := range ing.Status.LoadBalancer.Ingress {
-			if d.internet == nil {
-				d.internet = apps.Network.Internet(diagram.NodeLabel("Internet"))
-			}
+			d.GenerateInternet()
 
 			if lb.IP != "" {
 				d.diag.Connect(
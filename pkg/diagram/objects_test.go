@@ -0,0 +1,351 @@
+package diagram
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "app"
+
+func generateFromFakeCluster(t *testing.T, objects ...runtime.Object) *Diagram {
+	t.Helper()
+
+	d, err := NewDiagram(t.TempDir(), "k8s", "Kubernetes")
+	if err != nil {
+		t.Fatalf("NewDiagram() error = %v", err)
+	}
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, objects...))
+
+	return d
+}
+
+func discoverFakeCluster(t *testing.T, objects ...runtime.Object) *discovery.Objects {
+	t.Helper()
+
+	return discoverFakeClusterWith(t, nil, objects...)
+}
+
+func discoverFakeClusterWith(t *testing.T, opts []discovery.Option, objects ...runtime.Object) *discovery.Objects {
+	t.Helper()
+
+	objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+
+	client := fake.NewSimpleClientset(objects...)
+	fakeDiscovery := client.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.FakedServerVersion = &k8sversion.Info{GitVersion: "v1.21.0"}
+	fakeDiscovery.Resources = []*metav1.APIResourceList{{
+		GroupVersion: discoveryv1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "endpointslices"}},
+	}}
+
+	k := discovery.NewDiscoveryFromClient(context.Background(), client, opts...)
+
+	o, err := k.GenerateAll(testNamespace)
+	if err != nil {
+		t.Fatalf("GenerateAll() error = %v", err)
+	}
+
+	return o
+}
+
+// generateRedacted draws the objects redacted with sequential aliases, returning the redactor that aliased them.
+func generateRedacted(t *testing.T, o *discovery.Objects, opts ...Option) (*Diagram, *discovery.Redactor) {
+	t.Helper()
+
+	r, err := discovery.NewRedactor(discovery.RedactSequential, "", discovery.DefaultClusterDomain)
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	redacted, err := r.Redact(o)
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+
+	d, err := NewTempDiagram("k8s", "Kubernetes", opts...)
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+
+	d.GenerateDiagram(r.Alias(testNamespace), redacted)
+
+	return d, r
+}
+
+func countEdges(d *Diagram, start, end *diagram.Node) int {
+	count := 0
+
+	for _, e := range d.namespaceGroups[testNamespace].Edges() {
+		if e.Start() == start.ID() && e.End() == end.ID() {
+			count++
+		}
+	}
+
+	return count
+}
+
+func webService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+	}
+}
+
+func TestGenerateIngressesHostOnlyRule(t *testing.T) {
+	d := generateFromFakeCluster(t, webService(), &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: testNamespace},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Number: 80}},
+			},
+			Rules: []networkingv1.IngressRule{{Host: "host-only.example.com"}},
+		},
+	})
+
+	if got := countEdges(d, d.ingresses["ing"], d.services["web"]); got != 1 {
+		t.Errorf("got %d edges from ingress to default backend, want 1", got)
+	}
+}
+
+func TestGenerateIngressesResourceBackend(t *testing.T) {
+	apiGroup := "storage.example.com"
+	bucket := &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "Bucket", Name: "assets"}
+
+	d := generateFromFakeCluster(t, webService(), &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: testNamespace},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{Resource: bucket},
+			Rules: []networkingv1.IngressRule{{
+				Host: "web.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{
+						{
+							Path: "/",
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Name: "http"}},
+							},
+						},
+						{Path: "/static", Backend: networkingv1.IngressBackend{Resource: bucket}},
+						{Path: "/media", Backend: networkingv1.IngressBackend{Resource: bucket}},
+					},
+				}},
+			}},
+		},
+	})
+
+	if len(d.resources) != 1 {
+		t.Fatalf("got %d resource backends, want 1", len(d.resources))
+	}
+
+	n, ok := d.resources["Bucket.storage.example.com/assets"]
+	if !ok {
+		t.Fatalf("resource backend not found in %v", d.resources)
+	}
+
+	// One edge for the routes, one for the default backend.
+	if got := countEdges(d, d.ingresses["ing"], n); got != 2 {
+		t.Errorf("got %d edges from ingress to resource backend, want 2", got)
+	}
+
+	if got := countEdges(d, d.ingresses["ing"], d.services["web"]); got != 1 {
+		t.Errorf("got %d edges from ingress to service, want 1", got)
+	}
+}
+
+func TestGenerateIngressesMissingService(t *testing.T) {
+	d := generateFromFakeCluster(t, &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: testNamespace},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path: "/",
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{Name: "missing"},
+						},
+					}},
+				}},
+			}},
+		},
+	})
+
+	if got := len(d.namespaceGroups[testNamespace].Edges()); got != 0 {
+		t.Errorf("got %d edges, want 0", got)
+	}
+}
+
+func TestGenerateServicesNotReadyPods(t *testing.T) {
+	notReady := false
+	selector := map[string]string{"app": "web"}
+
+	pod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: selector}}
+	}
+
+	svc := webService()
+	svc.Spec.Selector = selector
+
+	d := generateFromFakeCluster(t, svc, pod("ready"), pod("not-ready"), pod("not-listed"), &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abcde",
+			Namespace: testNamespace,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "web"},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "ready"}},
+			{
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "not-ready"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &notReady},
+			},
+		},
+	})
+
+	styles := make(map[string]string)
+
+	for _, e := range d.namespaceGroups[testNamespace].Edges() {
+		for name, n := range d.pods {
+			if e.Start() == n.ID() && e.End() == d.services["web"].ID() {
+				styles[name] = e.Options.Style
+			}
+		}
+	}
+
+	want := map[string]string{"ready": "", "not-ready": "dashed", "not-listed": "dashed"}
+	for name, style := range want {
+		got, ok := styles[name]
+		if !ok {
+			t.Errorf("pod %s is not linked to the service", name)
+
+			continue
+		}
+
+		if got != style {
+			t.Errorf("got style %q for pod %s, want %q", got, name, style)
+		}
+	}
+}
+
+func TestGenerateExternalNames(t *testing.T) {
+	externalName := func(name, externalName string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: externalName},
+		}
+	}
+
+	d := generateFromFakeCluster(t,
+		externalName("db", "postgres.platform.svc.cluster.local"),
+		externalName("payments", "api.payments.example.com"),
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "platform"}},
+	)
+
+	if _, ok := d.namespaceGroups["platform"]; !ok {
+		t.Error("platform namespace group not found")
+	}
+
+	if _, ok := d.remoteServices["platform/postgres"]; !ok {
+		t.Errorf("referenced service not found in %v", d.remoteServices)
+	}
+
+	if _, ok := d.externalHosts["api.payments.example.com"]; !ok {
+		t.Errorf("external host not found in %v", d.externalHosts)
+	}
+
+	if got := len(d.diag.Edges()); got != 2 {
+		t.Errorf("got %d edges between namespaces, want 2", got)
+	}
+}
+
+func TestGenerateReplicaSetsScaledToZero(t *testing.T) {
+	zero := int32(0)
+	controller := true
+
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "web:2"}}},
+	}
+
+	deployment := func(name string, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Annotations: annotations},
+			Spec:       appsv1.DeploymentSpec{Replicas: &zero, Template: template},
+		}
+	}
+
+	replicaSet := func(owner, hash, image string, annotations map[string]string) *appsv1.ReplicaSet {
+		rsTemplate := *template.DeepCopy()
+		rsTemplate.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = hash
+		rsTemplate.Spec.Containers[0].Image = image
+
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            owner + "-" + hash,
+				Namespace:       testNamespace,
+				Annotations:     annotations,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: owner, Controller: &controller}},
+			},
+			Spec: appsv1.ReplicaSetSpec{Replicas: &zero, Template: rsTemplate},
+		}
+	}
+
+	revision := func(n string) map[string]string {
+		return map[string]string{revisionAnnotation: n}
+	}
+
+	objects := []runtime.Object{
+		deployment("web", revision("2")),
+		replicaSet("web", "new", "web:2", revision("2")),
+		replicaSet("web", "old", "web:1", revision("1")),
+		// Without revisions, the current replicaSet is told by its pod template.
+		deployment("api", nil),
+		replicaSet("api", "new", "web:2", nil),
+		replicaSet("api", "old", "web:1", nil),
+	}
+
+	tests := []struct {
+		name   string
+		option Option
+		want   []string
+	}{
+		{name: "scaled to zero", option: ShowScaledToZero(true), want: []string{"api-new", "web-new"}},
+		{name: "old replicaSets", option: ShowOldReplicaSets(true), want: []string{"api-old", "web-old"}},
+		{name: "none", option: ShowScaledToZero(false)},
+	}
+
+	for _, test := range tests {
+		d, err := NewTempDiagram("k8s", "Kubernetes", test.option)
+		if err != nil {
+			t.Fatalf("NewTempDiagram() error = %v", err)
+		}
+
+		d.GenerateDiagram(testNamespace, discoverFakeCluster(t, objects...))
+		d.Close()
+
+		var got []string
+		for name := range d.replicaSets {
+			got = append(got, name)
+		}
+
+		sort.Strings(got)
+
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: got replicaSets %v, want %v", test.name, got, test.want)
+		}
+	}
+}
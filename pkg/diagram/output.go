@@ -0,0 +1,130 @@
+package diagram
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format is a format a diagram can be written in.
+type Format string
+
+const (
+	// FormatDOT is the Graphviz DOT source of the diagram.
+	FormatDOT Format = "dot"
+	// FormatJSON is the graph of the objects and links of the diagram.
+	FormatJSON Format = "json"
+	// FormatMermaid is a Mermaid flowchart of the objects and links of the diagram.
+	FormatMermaid Format = "mmd"
+)
+
+// ErrUnknownFormat is returned when a diagram is written in a format it can't be written in.
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// imageFormats are the Graphviz output formats of the images a diagram can be written as.
+var imageFormats = []string{"svg", "png", "pdf", "jpg", "jpeg", "gif"}
+
+// imageAttribute matches the paths of the icons in the DOT source.
+var imageAttribute = regexp.MustCompile(`\bimage="([^"]+)"`)
+
+// FormatFromPath returns the format of a file, told by its extension, such as app.svg or app.mmd.
+func FormatFromPath(path string) (Format, bool) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+
+	switch Format(ext) {
+	case FormatDOT, FormatJSON, FormatMermaid:
+		return Format(ext), true
+	}
+
+	if contains(imageFormats, ext) {
+		return Format(ext), true
+	}
+
+	return "", false
+}
+
+// Write writes the diagram in a format: its DOT source, its graph as JSON, a Mermaid flowchart or an image.
+func (d *Diagram) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatDOT:
+		return d.WriteDOT(w)
+	case FormatJSON:
+		return d.WriteGraph(w)
+	case FormatMermaid:
+		return d.WriteMermaid(w)
+	}
+
+	if !contains(imageFormats, string(format)) {
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+
+	return d.WriteImage(w, string(format))
+}
+
+// SaveIcons copies the icons the diagram uses to a directory, at the paths its DOT source references them relatively
+// to that directory.
+func (d *Diagram) SaveIcons(dir string) error {
+	return d.withFiles(func(src string) error {
+		dotFile := filepath.Join(src, d.filename+".dot")
+
+		return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || path == dotFile {
+				return err
+			}
+
+			rel, err := filepath.Rel(src, path)
+			if err != nil {
+				return fmt.Errorf("copying icon: %w", err)
+			}
+
+			return copyFile(path, filepath.Join(dir, rel))
+		})
+	})
+}
+
+func copyFile(src, dst string) error {
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("reading icon: %w", err)
+	}
+
+	return writeFile(dst, content)
+}
+
+func writeFile(dst string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return fmt.Errorf("creating icon directory: %w", err)
+	}
+
+	if err := ioutil.WriteFile(dst, content, 0o600); err != nil {
+		return fmt.Errorf("writing icon: %w", err)
+	}
+
+	return nil
+}
+
+// WriteDOTWithIcons writes the DOT source of the diagram referencing its icons by absolute paths, after copying them
+// to a directory, so that it can be rendered from any directory, such as piped to Graphviz.
+func (d *Diagram) WriteDOTWithIcons(w io.Writer, dir string) error {
+	if err := d.SaveIcons(dir); err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("locating icons: %w", err)
+	}
+
+	dot := imageAttribute.ReplaceAll(d.dot, []byte(`image="`+filepath.ToSlash(abs)+`/$1"`))
+
+	if _, err := w.Write(dot); err != nil {
+		return fmt.Errorf("writing diagram: %w", err)
+	}
+
+	return nil
+}
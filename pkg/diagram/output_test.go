@@ -0,0 +1,96 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Format
+		ok   bool
+	}{
+		{path: "out/app.svg", want: "svg", ok: true},
+		{path: "app.PNG", want: "png", ok: true},
+		{path: "app.dot", want: FormatDOT, ok: true},
+		{path: "app.json", want: FormatJSON, ok: true},
+		{path: "app.mmd", want: FormatMermaid, ok: true},
+		{path: "k8s"},
+		{path: "app.txt"},
+	}
+
+	for _, test := range tests {
+		got, ok := FormatFromPath(test.path)
+		if got != test.want || ok != test.ok {
+			t.Errorf("FormatFromPath(%q) = %q, %t, want %q, %t", test.path, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func generateTemp(t *testing.T) *Diagram {
+	t.Helper()
+
+	d, err := Generate(context.Background(), discovery.FromObjects(discoverFakeCluster(t, webService())), testNamespace)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	return d
+}
+
+func TestWriteDOTWithIcons(t *testing.T) {
+	d := generateTemp(t)
+	dir := t.TempDir()
+
+	var dot bytes.Buffer
+	if err := d.WriteDOTWithIcons(&dot, dir); err != nil {
+		t.Fatalf("WriteDOTWithIcons() error = %v", err)
+	}
+
+	images := regexp.MustCompile(`image="([^"]+)"`).FindAllStringSubmatch(dot.String(), -1)
+	if len(images) == 0 {
+		t.Fatalf("DOT source doesn't reference icons:\n%s", dot.String())
+	}
+
+	for _, image := range images {
+		if !filepath.IsAbs(image[1]) {
+			t.Errorf("icon %s isn't referenced by an absolute path", image[1])
+		}
+
+		if _, err := os.Stat(image[1]); err != nil {
+			t.Errorf("icon %s not copied: %v", image[1], err)
+		}
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	d := generateTemp(t)
+
+	var mmd bytes.Buffer
+	if err := d.Write(&mmd, FormatMermaid); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	for _, want := range []string{"flowchart TB\n", `subgraph ns1["app"]`, `["Service<br/>web"]`, "  end\n"} {
+		if !strings.Contains(mmd.String(), want) {
+			t.Errorf("Mermaid flowchart doesn't contain %q:\n%s", want, mmd.String())
+		}
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	d := generateTemp(t)
+
+	if err := d.Write(&bytes.Buffer{}, "txt"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Write() error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
@@ -0,0 +1,87 @@
+package diagram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const edgeLabelFontSize = 8
+
+// serviceTypeBadge describes how a service is exposed.
+func serviceTypeBadge(svc corev1.Service) string {
+	switch {
+	case svc.Spec.Type == corev1.ServiceTypeExternalName:
+		return "ExternalName: " + svc.Spec.ExternalName
+	case svc.Spec.ClusterIP == corev1.ClusterIPNone:
+		return "headless"
+	case svc.Spec.Type == "":
+		return string(corev1.ServiceTypeClusterIP)
+	default:
+		return string(svc.Spec.Type)
+	}
+}
+
+// nodePortBadges lists the ports a service opens on every node, as port→nodePort/protocol.
+func nodePortBadges(svc corev1.Service) []string {
+	var badges []string
+
+	for _, p := range svc.Spec.Ports {
+		if p.NodePort == 0 {
+			continue
+		}
+
+		badges = append(badges, fmt.Sprintf("nodePort %d→%d/%s", p.Port, p.NodePort, protocol(p.Protocol)))
+	}
+
+	return badges
+}
+
+// servicePortsLabel describes how the ports of a service are mapped to the ports of its endpoints, as
+// port→targetPort/protocol.
+func servicePortsLabel(svc corev1.Service, ports []corev1.EndpointPort) string {
+	lines := make([]string, 0, len(ports))
+
+	for _, ep := range ports {
+		line := fmt.Sprintf("%d/%s", ep.Port, protocol(ep.Protocol))
+
+		if sp, ok := servicePort(svc, ep); ok {
+			line = strconv.Itoa(int(sp.Port)) + "→" + line
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\\n")
+}
+
+// servicePort finds the service port an endpoint port was generated from. Endpoint ports carry the name of their
+// service port, which may only be omitted when the service has a single port.
+func servicePort(svc corev1.Service, ep corev1.EndpointPort) (corev1.ServicePort, bool) {
+	for _, sp := range svc.Spec.Ports {
+		if sp.Name == ep.Name && protocol(sp.Protocol) == protocol(ep.Protocol) {
+			return sp, true
+		}
+	}
+
+	return corev1.ServicePort{}, false
+}
+
+func protocol(p corev1.Protocol) corev1.Protocol {
+	if p == "" {
+		return corev1.ProtocolTCP
+	}
+
+	return p
+}
+
+// edgeLabel sets a small label on an edge.
+func edgeLabel(label string) diagram.EdgeOption {
+	return func(o *diagram.EdgeOptions) {
+		o.Label = label
+		o.Font.Size = edgeLabelFontSize
+	}
+}
@@ -0,0 +1,150 @@
+package diagram
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestServiceBadges(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      corev1.ServiceSpec
+		badge     string
+		nodePorts []string
+	}{
+		{
+			name:  "default",
+			spec:  corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+			badge: "ClusterIP",
+		},
+		{
+			name:  "ClusterIP",
+			spec:  corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: "10.0.0.1"},
+			badge: "ClusterIP",
+		},
+		{
+			name:  "headless",
+			spec:  corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone},
+			badge: "headless",
+		},
+		{
+			name: "NodePort",
+			spec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeNodePort,
+				Ports: []corev1.ServicePort{
+					{Port: 80, NodePort: 30080},
+					{Port: 53, NodePort: 30053, Protocol: corev1.ProtocolUDP},
+				},
+			},
+			badge:     "NodePort",
+			nodePorts: []string{"nodePort 80→30080/TCP", "nodePort 53→30053/UDP"},
+		},
+		{
+			name: "LoadBalancer",
+			spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 443, NodePort: 31443}},
+			},
+			badge:     "LoadBalancer",
+			nodePorts: []string{"nodePort 443→31443/TCP"},
+		},
+		{
+			name:  "ExternalName",
+			spec:  corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "db.example.com"},
+			badge: "ExternalName: db.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		svc := corev1.Service{Spec: test.spec}
+
+		if got := serviceTypeBadge(svc); got != test.badge {
+			t.Errorf("%s: serviceTypeBadge() = %s, want %s", test.name, got, test.badge)
+		}
+
+		if got := nodePortBadges(svc); strings.Join(got, ",") != strings.Join(test.nodePorts, ",") {
+			t.Errorf("%s: nodePortBadges() = %v, want %v", test.name, got, test.nodePorts)
+		}
+	}
+}
+
+func TestServicePortsLabel(t *testing.T) {
+	svc := corev1.Service{Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{
+		{Name: "http", Port: 80, TargetPort: intstr.FromString("web")},
+		{Name: "dns", Port: 53, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt(5353)},
+	}}}
+
+	tests := []struct {
+		name  string
+		ports []corev1.EndpointPort
+		want  string
+	}{
+		{
+			name:  "named targetPort",
+			ports: []corev1.EndpointPort{{Name: "http", Port: 8080}},
+			want:  "80→8080/TCP",
+		},
+		{
+			name: "protocols",
+			ports: []corev1.EndpointPort{
+				{Name: "http", Port: 8080, Protocol: corev1.ProtocolTCP},
+				{Name: "dns", Port: 5353, Protocol: corev1.ProtocolUDP},
+			},
+			want: "80→8080/TCP\\n53→5353/UDP",
+		},
+		{
+			name:  "unknown port",
+			ports: []corev1.EndpointPort{{Name: "metrics", Port: 9090}},
+			want:  "9090/TCP",
+		},
+	}
+
+	for _, test := range tests {
+		if got := servicePortsLabel(svc, test.ports); got != test.want {
+			t.Errorf("%s: servicePortsLabel() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGenerateServicesPortsEdge(t *testing.T) {
+	portName, targetPort := "http", int32(8080)
+
+	svc := webService()
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+	svc.Spec.Ports = []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 30080, TargetPort: intstr.FromString("web")}}
+
+	d := generateFromFakeCluster(t,
+		svc,
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: testNamespace}},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-abcde",
+				Namespace: testNamespace,
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "web"},
+			},
+			Endpoints: []discoveryv1.Endpoint{{TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-1"}}},
+			Ports:     []discoveryv1.EndpointPort{{Name: &portName, Port: &targetPort}},
+		},
+	)
+
+	var labels []string
+
+	for _, e := range d.namespaceGroups[testNamespace].Edges() {
+		if e.Start() == d.pods["web-1"].ID() && e.End() == d.services["web"].ID() {
+			labels = append(labels, e.Options.Label)
+		}
+	}
+
+	if len(labels) != 1 || labels[0] != "80→8080/TCP" {
+		t.Errorf("got edges labeled %q from the pod to the service, want 80→8080/TCP", labels)
+	}
+
+	if want := "web\\nNodePort\\nnodePort 80→30080/TCP"; d.services["web"].Options.Label != want {
+		t.Errorf("service is labeled %q, want %q", d.services["web"].Options.Label, want)
+	}
+}
@@ -0,0 +1,255 @@
+package diagram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/blushft/go-diagrams/nodes/k8s"
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const (
+	rbacColor        = "#8E44AD"
+	maxRuleBadges    = 5
+	clusterAdminRole = "cluster-admin"
+	wildcard         = "*"
+	kindClusterRole  = "ClusterRole"
+)
+
+// rbacEdge styles the edges of the permission graph.
+func rbacEdge(o *diagram.EdgeOptions) {
+	o.Style = "dotted"
+	o.Color = rbacColor
+}
+
+// GenerateRBAC links the pods to their service account, to the bindings granting it permissions and to the roles
+// they bind.
+func (d *Diagram) GenerateRBAC(namespace string, o *discovery.Objects) {
+	if !d.showRBAC || o.ServiceAccounts == nil {
+		return
+	}
+
+	pods := make([]string, 0, len(d.podServiceAccounts))
+	for pod := range d.podServiceAccounts {
+		pods = append(pods, pod)
+	}
+
+	sort.Strings(pods)
+
+	for _, pod := range pods {
+		sa := d.GenerateServiceAccount(namespace, d.podServiceAccounts[pod])
+		d.namespaceGroups[namespace].Connect(d.pods[pod], sa, rbacEdge)
+	}
+
+	for _, b := range o.RoleBindings.Items {
+		if b.Namespace != namespace {
+			continue
+		}
+
+		d.GenerateBinding(namespace, b.Namespace, b.Subjects, func() *diagram.Node {
+			log.Debug().Msgf("Generating role binding: %s", b.Name)
+
+			n := k8s.Rbac.Rb(
+				diagram.NodeLabel(b.Name),
+				diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+				diagram.Width(d.style.NodeWidth),
+			)
+			d.register(n, "RoleBinding", namespace, b.Name, nil)
+			d.namespaceGroups[namespace].Connect(n, d.GenerateRole(namespace, b.RoleRef, o), rbacEdge)
+
+			return n
+		})
+	}
+
+	for _, b := range o.ClusterRoleBindings.Items {
+		d.GenerateBinding(namespace, "", b.Subjects, func() *diagram.Node {
+			log.Debug().Msgf("Generating cluster role binding: %s", b.Name)
+
+			n := k8s.Rbac.Crb(
+				diagram.NodeLabel(b.Name),
+				diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+				diagram.Width(d.style.NodeWidth),
+			)
+			d.register(n, "ClusterRoleBinding", "", b.Name, nil)
+			d.diag.Connect(n, d.GenerateRole(namespace, b.RoleRef, o), rbacEdge)
+
+			return n
+		})
+	}
+}
+
+// GenerateServiceAccount returns the node of a service account of the namespace.
+func (d *Diagram) GenerateServiceAccount(namespace, name string) *diagram.Node {
+	if n, ok := d.serviceAccounts[name]; ok {
+		return n
+	}
+
+	log.Debug().Msgf("Generating service account: %s", name)
+
+	d.serviceAccounts[name] = k8s.Rbac.Sa(
+		diagram.NodeLabel(name),
+		diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+		diagram.Width(d.style.NodeWidth),
+	)
+	d.namespaceGroups[namespace].Add(d.serviceAccounts[name])
+	d.register(d.serviceAccounts[name], "ServiceAccount", namespace, name, nil)
+
+	return d.serviceAccounts[name]
+}
+
+// GenerateBinding draws a binding when it grants permissions to service accounts used by the drawn pods, directly or
+// through the groups of service accounts, and links them to it.
+func (d *Diagram) GenerateBinding(
+	namespace, bindingNamespace string, subjects []rbacv1.Subject, generate func() *diagram.Node,
+) {
+	bound := make(map[string]bool)
+
+	for _, s := range subjects {
+		name, ok := discovery.ServiceAccountSubject(s, bindingNamespace, namespace)
+		if !ok {
+			continue
+		}
+
+		for sa := range d.serviceAccounts {
+			if name == "" || name == sa {
+				bound[sa] = true
+			}
+		}
+	}
+
+	if len(bound) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(bound))
+	for sa := range bound {
+		names = append(names, sa)
+	}
+
+	sort.Strings(names)
+
+	binding := generate()
+
+	for _, sa := range names {
+		d.diag.Connect(d.serviceAccounts[sa], binding, rbacEdge)
+	}
+}
+
+// GenerateRole returns the node of the role referenced by a binding, labeled with a summary of its rules.
+func (d *Diagram) GenerateRole(namespace string, ref rbacv1.RoleRef, o *discovery.Objects) *diagram.Node {
+	key := ref.Kind + "/" + ref.Name
+	if n, ok := d.roles[key]; ok {
+		return n
+	}
+
+	log.Debug().Msgf("Generating role: %s", key)
+
+	var rules []rbacv1.PolicyRule
+
+	found := false
+
+	if ref.Kind == kindClusterRole {
+		d.roles[key] = k8s.Rbac.CRole(
+			diagram.NodeLabel(ref.Name),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
+		)
+		d.diag.Add(d.roles[key])
+		d.register(d.roles[key], kindClusterRole, "", ref.Name, nil)
+
+		for _, r := range o.ClusterRoles.Items {
+			if r.Name == ref.Name {
+				rules, found = r.Rules, true
+			}
+		}
+	} else {
+		d.roles[key] = k8s.Rbac.Role(
+			diagram.NodeLabel(ref.Name),
+			diagram.SetFontOptions(diagram.Font{Size: d.style.FontSize}),
+			diagram.Width(d.style.NodeWidth),
+		)
+		d.namespaceGroups[namespace].Add(d.roles[key])
+		d.register(d.roles[key], ref.Kind, namespace, ref.Name, nil)
+
+		for _, r := range o.Roles.Items {
+			if r.Name == ref.Name && r.Namespace == namespace {
+				rules, found = r.Rules, true
+			}
+		}
+	}
+
+	if !found {
+		d.badge(d.roles[key], "not found")
+	}
+
+	if warning := rulesWarning(ref.Name, rules); warning != "" {
+		paint(d.roles[key], healthCritical)
+		d.badge(d.roles[key], warning)
+	}
+
+	for _, badge := range rulesBadges(rules) {
+		d.badge(d.roles[key], badge)
+	}
+
+	return d.roles[key]
+}
+
+// rulesWarning flags the roles granting every permission, or using wildcards.
+func rulesWarning(name string, rules []rbacv1.PolicyRule) string {
+	if name == clusterAdminRole {
+		return clusterAdminRole
+	}
+
+	for _, r := range rules {
+		if contains(r.Verbs, wildcard) && contains(r.Resources, wildcard) && contains(r.APIGroups, wildcard) {
+			return "full access"
+		}
+	}
+
+	for _, r := range rules {
+		if contains(r.Verbs, wildcard) || contains(r.Resources, wildcard) || contains(r.APIGroups, wildcard) ||
+			contains(r.NonResourceURLs, wildcard) {
+			return "wildcard"
+		}
+	}
+
+	return ""
+}
+
+// rulesBadges summarizes the rules of a role as verbs: resources.
+func rulesBadges(rules []rbacv1.PolicyRule) []string {
+	var badges []string
+
+	for _, r := range rules {
+		resources := r.Resources
+		if len(resources) == 0 {
+			resources = r.NonResourceURLs
+		}
+
+		if len(r.ResourceNames) > 0 {
+			resources = append(append([]string{}, resources...), "("+strings.Join(r.ResourceNames, ",")+")")
+		}
+
+		badges = append(badges, strings.Join(r.Verbs, ",")+": "+strings.Join(resources, ","))
+	}
+
+	if len(badges) > maxRuleBadges {
+		badges = append(badges[:maxRuleBadges], fmt.Sprintf("… %d more", len(badges)-maxRuleBadges))
+	}
+
+	return badges
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
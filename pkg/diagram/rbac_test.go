@@ -0,0 +1,155 @@
+package diagram
+
+import (
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRulesWarning(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  string
+		rules []rbacv1.PolicyRule
+		want  string
+	}{
+		{
+			name: "cluster-admin",
+			role: "cluster-admin",
+			want: "cluster-admin",
+		},
+		{
+			name:  "full access",
+			role:  "admin-like",
+			rules: []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+			want:  "full access",
+		},
+		{
+			name:  "wildcard verbs",
+			role:  "secrets-writer",
+			rules: []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+			want:  "wildcard",
+		},
+		{
+			name: "read only",
+			role: "viewer",
+			rules: []rbacv1.PolicyRule{
+				{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			if got := rulesWarning(test.role, test.rules); got != test.want {
+				t.Errorf("rulesWarning() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func rbacObjects() []runtime.Object {
+	pod := func(name, serviceAccount string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       corev1.PodSpec{ServiceAccountName: serviceAccount},
+		}
+	}
+
+	serviceAccount := func(name string) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace}}
+	}
+
+	clusterRoleBinding := func(name, role string, subject rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Subjects:   []rbacv1.Subject{subject},
+			RoleRef:    rbacv1.RoleRef{Kind: kindClusterRole, Name: role},
+		}
+	}
+
+	clusterRole := func(name string) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+		}
+	}
+
+	return []runtime.Object{
+		pod("web", "web"),
+		pod("worker", ""),
+		serviceAccount("web"),
+		serviceAccount("default"),
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: testNamespace},
+			Rules: []rbacv1.PolicyRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-reader", Namespace: testNamespace},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "web"}},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+		},
+		clusterRoleBinding("all-viewers", "viewer", rbacv1.Subject{
+			Kind: rbacv1.GroupKind,
+			Name: "system:serviceaccounts:app",
+		}),
+		clusterRoleBinding("user-metrics", "metrics", rbacv1.Subject{
+			Kind: rbacv1.UserKind,
+			Name: "system:serviceaccount:app:default",
+		}),
+		clusterRoleBinding("other-admin", "admin", rbacv1.Subject{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      "web",
+			Namespace: "other",
+		}),
+		clusterRole("viewer"),
+		clusterRole("metrics"),
+		clusterRole("admin"),
+	}
+}
+
+func TestGenerateRBAC(t *testing.T) {
+	d, err := NewTempDiagram("k8s", "Kubernetes", ShowRBAC(true))
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	o := discoverFakeClusterWith(t, []discovery.Option{discovery.WithRBAC(true)}, rbacObjects()...)
+	d.GenerateDiagram(testNamespace, o)
+
+	edges := make(map[string]bool)
+	for _, e := range d.Graph().Edges {
+		edges[e.From+" → "+e.To] = true
+	}
+
+	for _, want := range []string{
+		"Pod/app/web → ServiceAccount/app/web",
+		"Pod/app/worker → ServiceAccount/app/default",
+		"ServiceAccount/app/web → RoleBinding/app/web-reader",
+		"RoleBinding/app/web-reader → Role/app/reader",
+		"ServiceAccount/app/web → ClusterRoleBinding/all-viewers",
+		"ServiceAccount/app/default → ClusterRoleBinding/all-viewers",
+		"ClusterRoleBinding/all-viewers → ClusterRole/viewer",
+		"ServiceAccount/app/default → ClusterRoleBinding/user-metrics",
+		"ClusterRoleBinding/user-metrics → ClusterRole/metrics",
+	} {
+		if !edges[want] {
+			t.Errorf("edge %s not found in %v", want, edges)
+		}
+	}
+
+	for _, n := range d.Graph().Nodes {
+		if n.Name == "other-admin" || n.Name == "admin" {
+			t.Errorf("got %s bound to a service account of another namespace", n.Key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package diagram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateRedactedRBACAndContainers(t *testing.T) {
+	class := "nginx-internal"
+
+	objects := append(rbacObjects(),
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: testNamespace},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "api", Image: "registry.corp.internal:5000/shop/api:1.0"},
+				{Name: "proxy", Image: "envoy:1.28"},
+			}},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: testNamespace},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: &class,
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{"shop.corp.internal"}, SecretName: "shop-corp-internal-tls"},
+				},
+			},
+		},
+	)
+
+	o := discoverFakeClusterWith(t, []discovery.Option{discovery.WithRBAC(true)}, objects...)
+
+	d, r := generateRedacted(t, o, ShowRBAC(true), WithDetail(DetailContainers))
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, leak := range []string{
+		"reader", "viewer", "registry.corp.internal", "shop-corp-internal-tls", "nginx-internal", "corp.internal",
+	} {
+		if strings.Contains(dot.String(), leak) {
+			t.Errorf("redacted DOT source contains %s:\n%s", leak, dot.String())
+		}
+	}
+
+	for _, want := range []string{r.Alias("registry.corp.internal:5000") + "/shop/api:1.0", "envoy:1.28"} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("redacted DOT source doesn't contain the image %s", want)
+		}
+	}
+
+	// The bindings of the service accounts by user name and group still match their redacted namespace.
+	edges := make(map[string]bool)
+	for _, e := range d.Graph().Edges {
+		edges[e.From+" → "+e.To] = true
+	}
+
+	ns, sa := r.Alias(testNamespace), r.Alias("default")
+
+	for _, want := range []string{
+		"ServiceAccount/" + ns + "/" + sa + " → ClusterRoleBinding/" + r.Alias("all-viewers"),
+		"ServiceAccount/" + ns + "/" + sa + " → ClusterRoleBinding/" + r.Alias("user-metrics"),
+		"RoleBinding/" + ns + "/" + r.Alias("web-reader") + " → Role/" + ns + "/" + r.Alias("reader"),
+	} {
+		if !edges[want] {
+			t.Errorf("edge %s not found in %v", want, edges)
+		}
+	}
+}
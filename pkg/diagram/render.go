@@ -0,0 +1,334 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+const (
+	defaultLabel = "Kubernetes"
+	graphvizDot  = "dot"
+)
+
+// WithLabel sets the label of the diagram.
+func WithLabel(label string) Option {
+	return func(d *Diagram) {
+		d.label = label
+	}
+}
+
+// Generate draws the objects of a namespace read from a source, to be written with WriteDOT, WriteImage or
+// WriteGraph, or inspected with Graph. The diagram is rendered in memory: WriteImage and SaveIcons restore its icons
+// in a temporary directory they remove, so that it needs no Close.
+func Generate(ctx context.Context, source discovery.Source, namespace string, opts ...Option) (*Diagram, error) {
+	o, err := source.Objects(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if !o.HasNamespace(namespace) {
+		return nil, &discovery.Error{
+			Reason: discovery.ErrNamespaceNotFound,
+			Err:    fmt.Errorf("namespace %s not found", namespace),
+		}
+	}
+
+	d, err := NewTempDiagram(namespace, defaultLabel, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	d.GenerateDiagram(namespace, o)
+
+	if _, err := d.render(); err != nil {
+		d.Close()
+
+		return nil, err
+	}
+
+	files, err := readFiles(d.outputDir, d.filename+".dot")
+	if err != nil {
+		d.Close()
+
+		return nil, renderError{err: err}
+	}
+
+	if err := d.Close(); err != nil {
+		return nil, err
+	}
+
+	d.files, d.outputDir, d.tempDir = files, "", ""
+
+	return d, nil
+}
+
+// readFiles reads the icons of a diagram rendered in a directory, by path relative to it, skipping its DOT source.
+func readFiles(dir, dotFile string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || path == filepath.Join(dir, dotFile) {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("reading icon: %w", err)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading icon: %w", err)
+		}
+
+		files[rel] = content
+
+		return nil
+	})
+
+	return files, err
+}
+
+// withFiles calls a function with the directory holding the icons of the diagram: its output directory, or a
+// temporary directory they are restored in for the diagrams rendered in memory by Generate.
+func (d *Diagram) withFiles(f func(dir string) error) error {
+	if _, err := d.render(); err != nil {
+		return err
+	}
+
+	if d.files == nil {
+		return f(d.outputDir)
+	}
+
+	dir, err := ioutil.TempDir("", "k8s-diagrams")
+	if err != nil {
+		return fmt.Errorf("creating diagram directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for rel, content := range d.files {
+		if err := writeFile(filepath.Join(dir, rel), content); err != nil {
+			return err
+		}
+	}
+
+	return f(dir)
+}
+
+// NewTempDiagram creates a diagram rendered in a temporary directory, removed by Close, to be written with Write.
+func NewTempDiagram(filename, label string, opts ...Option) (*Diagram, error) {
+	dir, err := ioutil.TempDir("", "k8s-diagrams")
+	if err != nil {
+		return nil, fmt.Errorf("creating diagram directory: %w", err)
+	}
+
+	d, err := NewDiagram(filepath.Join(dir, "diagram"), filename, label, opts...)
+	if err != nil {
+		os.RemoveAll(dir)
+
+		return nil, err
+	}
+
+	d.tempDir = dir
+
+	return d, nil
+}
+
+// Close removes the temporary directory of a diagram created by NewTempDiagram.
+func (d *Diagram) Close() error {
+	if d.tempDir == "" {
+		return nil
+	}
+
+	if err := os.RemoveAll(d.tempDir); err != nil {
+		return fmt.Errorf("removing diagram directory: %w", err)
+	}
+
+	return nil
+}
+
+// render writes the diagram to its output directory once, and returns its DOT source.
+func (d *Diagram) render() ([]byte, error) {
+	if d.rendered {
+		return d.dot, d.renderErr
+	}
+
+	d.rendered = true
+
+	if d.showLegend {
+		d.applyLegend()
+	}
+
+	d.applyStyle()
+
+	if enabled(d.style.Layered) {
+		d.applyLayers()
+	}
+
+	if d.showLegend {
+		if err := d.labelFooter(); err != nil {
+			d.renderErr = renderError{err: err}
+			return d.dot, d.renderErr
+		}
+	}
+
+	if err := d.renderFiles(); err != nil {
+		d.renderErr = renderError{err: err}
+	}
+
+	return d.dot, d.renderErr
+}
+
+// renderFiles writes the DOT source and the icons of the diagram to its output directory.
+func (d *Diagram) renderFiles() error {
+	if err := d.diag.Render(); err != nil {
+		return err
+	}
+
+	if err := d.copyIcons(); err != nil {
+		return err
+	}
+
+	dot, err := ioutil.ReadFile(filepath.Join(d.outputDir, d.filename+".dot"))
+	if err != nil {
+		return fmt.Errorf("reading diagram: %w", err)
+	}
+
+	d.dot = dot
+
+	if !enabled(d.style.Grayscale) {
+		return nil
+	}
+
+	if err := d.grayscale(); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(d.outputDir, d.filename+".dot"), d.dot, os.ModePerm); err != nil {
+		return fmt.Errorf("writing diagram: %w", err)
+	}
+
+	return nil
+}
+
+// WriteDOT writes the Graphviz DOT source of the diagram. Its icons are referenced relatively to the output directory.
+func (d *Diagram) WriteDOT(w io.Writer) error {
+	dot, err := d.render()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(dot); err != nil {
+		return fmt.Errorf("writing diagram: %w", err)
+	}
+
+	return nil
+}
+
+// WriteImage writes the diagram as an image in a Graphviz output format, such as svg or png, with its icons. The
+// icons of SVG images are inlined as data URIs, for them not to reference the temporary directory the diagram is
+// rendered in. It requires the Graphviz dot command.
+func (d *Diagram) WriteImage(w io.Writer, format string) error {
+	dot, err := d.render()
+	if err != nil {
+		return err
+	}
+
+	path, err := exec.LookPath(graphvizDot)
+	if err != nil {
+		return renderError{err: fmt.Errorf("rendering %s images requires Graphviz: %w", format, err)}
+	}
+
+	var image []byte
+
+	err = d.withFiles(func(dir string) error {
+		var stdout, stderr bytes.Buffer
+
+		cmd := exec.Command(path, "-T"+format)
+		cmd.Dir = dir
+		cmd.Stdin = bytes.NewReader(dot)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return renderError{err: fmt.Errorf("running Graphviz: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))}
+		}
+
+		image = stdout.Bytes()
+
+		if format != "svg" && !strings.HasPrefix(format, "svg:") {
+			return nil
+		}
+
+		inlined, err := inlineImages(image, dir)
+		image = inlined
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(image); err != nil {
+		return fmt.Errorf("writing diagram: %w", err)
+	}
+
+	return nil
+}
+
+// svgImage matches the files the images of an SVG image reference, as Graphviz writes them.
+var svgImage = regexp.MustCompile(`(<image\b[^>]*?\s(?:xlink:)?href=")([^"#:]+)"`)
+
+// inlineImages replaces the files the images of an SVG image reference, relative to a directory, with data URIs.
+func inlineImages(svg []byte, dir string) ([]byte, error) {
+	var err error
+
+	inlined := svgImage.ReplaceAllFunc(svg, func(m []byte) []byte {
+		sub := svgImage.FindSubmatch(m)
+		path := html.UnescapeString(string(sub[2]))
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		content, readErr := ioutil.ReadFile(path)
+		if readErr != nil {
+			if err == nil {
+				err = fmt.Errorf("inlining icon: %w", readErr)
+			}
+
+			return m
+		}
+
+		mediaType := mime.TypeByExtension(filepath.Ext(path))
+		if mediaType == "" {
+			mediaType = http.DetectContentType(content)
+		}
+
+		return []byte(fmt.Sprintf(`%sdata:%s;base64,%s"`, sub[1], mediaType, base64.StdEncoding.EncodeToString(content)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inlined, nil
+}
+
+// WriteGraph writes the objects and links of the diagram as JSON, as SaveGraph does.
+func (d *Diagram) WriteGraph(w io.Writer) error {
+	return SaveGraph(w, d.Graph())
+}
@@ -0,0 +1,121 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+func TestGenerate(t *testing.T) {
+	source := discovery.FromObjects(discoverFakeCluster(t, webService()))
+
+	d, err := Generate(context.Background(), source, testNamespace, WithLabel("Portal"))
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{"Portal", "web"} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT source doesn't contain %q:\n%s", want, dot.String())
+		}
+	}
+
+	var graph bytes.Buffer
+	if err := d.WriteGraph(&graph); err != nil {
+		t.Fatalf("WriteGraph() error = %v", err)
+	}
+
+	if !strings.Contains(graph.String(), `"Service/app/web"`) {
+		t.Errorf("graph doesn't contain the service:\n%s", graph.String())
+	}
+
+	// The diagram is rendered in memory, without a directory to remove.
+	if d.tempDir != "" || d.outputDir != "" {
+		t.Errorf("diagram keeps the directories %q and %q", d.tempDir, d.outputDir)
+	}
+
+	icons := t.TempDir()
+	if err := d.SaveIcons(icons); err != nil {
+		t.Fatalf("SaveIcons() error = %v", err)
+	}
+
+	for _, image := range imageAttribute.FindAllSubmatch(dot.Bytes(), -1) {
+		if _, err := os.Stat(filepath.Join(icons, string(image[1]))); err != nil {
+			t.Errorf("icon %s not saved: %v", image[1], err)
+		}
+	}
+}
+
+func TestGenerateMissingNamespace(t *testing.T) {
+	source := discovery.FromObjects(discoverFakeCluster(t))
+
+	_, err := Generate(context.Background(), source, "missing")
+	if !errors.Is(err, discovery.ErrNamespaceNotFound) {
+		t.Errorf("Generate() error = %v, want %v", err, discovery.ErrNamespaceNotFound)
+	}
+}
+
+func TestWriteImage(t *testing.T) {
+	if _, err := exec.LookPath(graphvizDot); err != nil {
+		t.Skip("Graphviz is not installed")
+	}
+
+	d, err := Generate(context.Background(), discovery.FromObjects(discoverFakeCluster(t, webService())), testNamespace)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var svg bytes.Buffer
+	if err := d.WriteImage(&svg, "svg"); err != nil {
+		t.Fatalf("WriteImage() error = %v", err)
+	}
+
+	if !strings.Contains(svg.String(), "<svg") {
+		t.Errorf("WriteImage() didn't write an SVG image:\n%s", svg.String())
+	}
+
+	if !strings.Contains(svg.String(), `xlink:href="data:image/png;base64,`) {
+		t.Errorf("WriteImage() didn't inline the icons:\n%s", svg.String())
+	}
+}
+
+func TestInlineImages(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(dir, "assets"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "assets", "pod.png"), []byte("icon"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	svg := `<a xlink:href="docs/pod.html"><image xlink:href="assets/pod.png" width="30px"/></a>`
+
+	got, err := inlineImages([]byte(svg), dir)
+	if err != nil {
+		t.Fatalf("inlineImages() error = %v", err)
+	}
+
+	want := `<a xlink:href="docs/pod.html"><image xlink:href="data:image/png;base64,aWNvbg==" width="30px"/></a>`
+	if string(got) != want {
+		t.Errorf("inlineImages() = %s, want %s", got, want)
+	}
+
+	if _, err := inlineImages([]byte(`<image xlink:href="assets/missing.png"/>`), dir); err == nil {
+		t.Error("inlineImages() didn't fail on a missing icon")
+	}
+}
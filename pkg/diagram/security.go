@@ -0,0 +1,281 @@
+package diagram
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/rs/zerolog/log"
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const (
+	securityColor    = "#C0392B"
+	securityPenWidth = "2"
+)
+
+// ShowSecurity marks the pods with risky settings, such as privileged containers or host namespaces, and the services
+// and ingresses exposed to the Internet, to draw the attack surface of the namespace.
+func ShowSecurity(show bool) Option {
+	return func(d *Diagram) {
+		d.showSecurity = show
+	}
+}
+
+// exposeNode outlines a node in red, keeping its health fill.
+func exposeNode(n *diagram.Node) {
+	style := "rounded"
+	if n.Options.Attributes["style"] == "filled" {
+		style += ",filled"
+	}
+
+	// Nodes drawn with an icon have no border, a box is drawn around them.
+	n.Options.Attributes["shape"] = "box"
+	n.Options.Attributes["style"] = style
+	n.Options.Attributes["color"] = securityColor
+	n.Options.Attributes["penwidth"] = securityPenWidth
+}
+
+// GenerateSecurity marks the drawn pods with risky settings, and the services and ingresses reachable from the
+// Internet. The edges coming from the Internet are drawn in red.
+func (d *Diagram) GenerateSecurity(namespace string, o *discovery.Objects) {
+	if !d.showSecurity {
+		return
+	}
+
+	for _, v := range o.Pods.Items {
+		n, ok := d.pods[v.Name]
+		if v.Namespace != namespace || !ok {
+			continue
+		}
+
+		risks := podRisks(v, serviceAccountAutomount(v, o.ServiceAccounts))
+		if len(risks) == 0 {
+			continue
+		}
+
+		log.Debug().Msgf("Marking pod: %s", v.Name)
+
+		exposeNode(n)
+
+		for _, risk := range risks {
+			d.badge(n, risk)
+		}
+	}
+
+	d.GenerateExposure(namespace, o)
+}
+
+// GenerateExposure marks the services and ingresses reachable from the Internet, either through the address of
+// their load balancer or through an exposed ingress.
+func (d *Diagram) GenerateExposure(namespace string, o *discovery.Objects) {
+	exposed := make(map[*diagram.Node][]string)
+
+	for _, svc := range o.Services.Items {
+		if svc.Namespace == namespace && len(svc.Status.LoadBalancer.Ingress) > 0 && d.services[svc.Name] != nil {
+			exposed[d.services[svc.Name]] = append(exposed[d.services[svc.Name]], "load balancer")
+		}
+	}
+
+	for _, ing := range o.Ingresses.Items {
+		if ing.Namespace != namespace || len(ing.Status.LoadBalancer.Ingress) == 0 || d.ingresses[ing.Name] == nil {
+			continue
+		}
+
+		exposed[d.ingresses[ing.Name]] = append(exposed[d.ingresses[ing.Name]], "load balancer")
+
+		for _, name := range ingressServices(ing) {
+			if n, ok := d.services[name]; ok {
+				exposed[n] = append(exposed[n], "ingress "+ing.Name)
+			}
+		}
+	}
+
+	for n, via := range exposed {
+		exposeNode(n)
+		d.badge(n, "exposed via "+strings.Join(via, ", "))
+	}
+
+	if d.internet == nil {
+		return
+	}
+
+	for _, e := range d.allEdges() {
+		if e.Start() == d.internet.ID() {
+			e.Options.Color = securityColor
+			e.Options.Attributes["penwidth"] = securityPenWidth
+		}
+	}
+}
+
+// ingressServices lists the names of the services an ingress routes to, once each.
+func ingressServices(ing networkingv1.Ingress) []string {
+	backends := []networkingv1.IngressBackend{}
+	if ing.Spec.DefaultBackend != nil {
+		backends = append(backends, *ing.Spec.DefaultBackend)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			backends = append(backends, path.Backend)
+		}
+	}
+
+	seen := make(map[string]bool)
+
+	var names []string
+
+	for _, b := range backends {
+		if b.Service == nil || seen[b.Service.Name] {
+			continue
+		}
+
+		seen[b.Service.Name] = true
+		names = append(names, b.Service.Name)
+	}
+
+	return names
+}
+
+// serviceAccountAutomount tells if the service account of a pod mounts its token by default. Service accounts are only
+// known when discovered with the RBAC objects, and mount their token by default otherwise.
+func serviceAccountAutomount(v corev1.Pod, accounts *corev1.ServiceAccountList) bool {
+	if accounts == nil {
+		return true
+	}
+
+	name := v.Spec.ServiceAccountName
+	if name == "" {
+		name = "default"
+	}
+
+	for _, sa := range accounts.Items {
+		if sa.Namespace == v.Namespace && sa.Name == name && sa.AutomountServiceAccountToken != nil {
+			return *sa.AutomountServiceAccountToken
+		}
+	}
+
+	return true
+}
+
+// podRisks lists the risky settings of a pod: privileged containers, host namespaces and paths, containers allowed
+// to run as root or without limits, and a mounted service account token.
+func podRisks(v corev1.Pod, automount bool) []string {
+	var risks []string
+
+	containers := append(append([]corev1.Container{}, v.Spec.InitContainers...), v.Spec.Containers...)
+
+	if names := containersWith(containers, privileged); len(names) > 0 {
+		risks = append(risks, "privileged: "+strings.Join(names, ", "))
+	}
+
+	if v.Spec.HostNetwork {
+		risks = append(risks, "hostNetwork")
+	}
+
+	if v.Spec.HostPID {
+		risks = append(risks, "hostPID")
+	}
+
+	if v.Spec.HostIPC {
+		risks = append(risks, "hostIPC")
+	}
+
+	if paths := hostPaths(v); len(paths) > 0 {
+		risks = append(risks, "hostPath: "+strings.Join(paths, ", "))
+	}
+
+	root := func(c corev1.Container) bool {
+		return runsAsRoot(v, c)
+	}
+
+	if names := containersWith(containers, root); len(names) > 0 {
+		risks = append(risks, "root: "+strings.Join(names, ", "))
+	}
+
+	if names := containersWith(v.Spec.Containers, withoutLimits); len(names) > 0 {
+		risks = append(risks, "no limits: "+strings.Join(names, ", "))
+	}
+
+	if v.Spec.AutomountServiceAccountToken != nil {
+		automount = *v.Spec.AutomountServiceAccountToken
+	}
+
+	if automount {
+		risks = append(risks, "service account token")
+	}
+
+	return risks
+}
+
+func containersWith(containers []corev1.Container, risky func(corev1.Container) bool) []string {
+	var names []string
+
+	for _, c := range containers {
+		if risky(c) {
+			names = append(names, c.Name)
+		}
+	}
+
+	return names
+}
+
+func privileged(c corev1.Container) bool {
+	return c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged
+}
+
+// runsAsRoot tells if a container runs as root, or is allowed to when its image doesn't set a user. The settings of
+// the container override the ones of the pod.
+func runsAsRoot(v corev1.Pod, c corev1.Container) bool {
+	var (
+		user    *int64
+		nonRoot *bool
+	)
+
+	if sc := v.Spec.SecurityContext; sc != nil {
+		user, nonRoot = sc.RunAsUser, sc.RunAsNonRoot
+	}
+
+	if sc := c.SecurityContext; sc != nil {
+		if sc.RunAsUser != nil {
+			user = sc.RunAsUser
+		}
+
+		if sc.RunAsNonRoot != nil {
+			nonRoot = sc.RunAsNonRoot
+		}
+	}
+
+	if user != nil {
+		return *user == 0
+	}
+
+	return nonRoot == nil || !*nonRoot
+}
+
+func withoutLimits(c corev1.Container) bool {
+	_, cpu := c.Resources.Limits[corev1.ResourceCPU]
+	_, memory := c.Resources.Limits[corev1.ResourceMemory]
+
+	return !cpu || !memory
+}
+
+func hostPaths(v corev1.Pod) []string {
+	var paths []string
+
+	for _, vol := range v.Spec.Volumes {
+		if vol.HostPath != nil {
+			paths = append(paths, vol.HostPath.Path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
@@ -0,0 +1,106 @@
+package diagram
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodRisks(t *testing.T) {
+	yes, no := true, false
+	root, user := int64(0), int64(1000)
+	limits := corev1.ResourceRequirements{Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("64Mi"),
+	}}
+
+	tests := []struct {
+		name      string
+		spec      corev1.PodSpec
+		automount bool
+		want      []string
+	}{
+		{
+			name: "hardened",
+			spec: corev1.PodSpec{
+				SecurityContext:              &corev1.PodSecurityContext{RunAsNonRoot: &yes},
+				AutomountServiceAccountToken: &no,
+				Containers:                   []corev1.Container{{Name: "web", Resources: limits}},
+			},
+			automount: true,
+		},
+		{
+			name: "privileged agent",
+			spec: corev1.PodSpec{
+				HostNetwork: true,
+				HostPID:     true,
+				Volumes: []corev1.Volume{{Name: "docker", VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"},
+				}}},
+				Containers: []corev1.Container{{
+					Name:            "agent",
+					SecurityContext: &corev1.SecurityContext{Privileged: &yes, RunAsUser: &root},
+				}},
+			},
+			want: []string{
+				"privileged: agent",
+				"hostNetwork",
+				"hostPID",
+				"hostPath: /var/run/docker.sock",
+				"root: agent",
+				"no limits: agent",
+			},
+		},
+		{
+			name: "container overriding the pod user",
+			spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: &root},
+				Containers: []corev1.Container{
+					{Name: "web", Resources: limits, SecurityContext: &corev1.SecurityContext{RunAsUser: &user}},
+					{Name: "proxy", Resources: limits},
+				},
+			},
+			automount: true,
+			want:      []string{"root: proxy", "service account token"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podRisks(corev1.Pod{Spec: tt.spec}, tt.automount); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("podRisks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateExposure(t *testing.T) {
+	d, err := NewDiagram(t.TempDir(), "k8s", "Kubernetes", ShowSecurity(true))
+	if err != nil {
+		t.Fatalf("NewDiagram() error = %v", err)
+	}
+
+	lb := corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}}
+	objects := diffObjects("nginx:1.21", "web")
+	objects[1].(*corev1.Service).Status.LoadBalancer = lb
+	objects = append(objects, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: testNamespace}})
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, objects...))
+
+	if got := d.services["web"].Options.Attributes["color"]; got != securityColor {
+		t.Errorf("got exposed service color %q, want %q", got, securityColor)
+	}
+
+	if got := d.services["db"].Options.Attributes["color"]; got != "" {
+		t.Errorf("got internal service color %q, want none", got)
+	}
+
+	for _, e := range d.diag.Edges() {
+		if e.Start() == d.internet.ID() && e.Options.Color != securityColor {
+			t.Errorf("got Internet edge color %q, want %q", e.Options.Color, securityColor)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package diagram
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/blushft/go-diagrams/diagram"
+)
+
+const (
+	defaultEdgeColor = "#7B8894"
+	defaultFontColor = "#2D3436"
+)
+
+// Style sets how a diagram is drawn: its layout, the fonts, sizes and colors of its nodes, edges and groups, and the
+// style of the nodes of some kinds or labels.
+type Style struct {
+	// Direction is the Graphviz rank direction: TB, LR, BT or RL.
+	Direction string `json:"direction,omitempty"`
+	// NodeSep is the space between the nodes of a rank, in inches.
+	NodeSep float64 `json:"nodesep,omitempty"`
+	// RankSep is the space between the ranks, in inches.
+	RankSep float64 `json:"ranksep,omitempty"`
+	// Splines is the Graphviz routing of the edges: curved, ortho, polyline, spline or line.
+	Splines string `json:"splines,omitempty"`
+	// Layered pins the Internet, the ingresses, services, workloads and pods onto consecutive ranks, when set to true.
+	Layered       *bool   `json:"layered,omitempty"`
+	FontSize      float64 `json:"fontSize,omitempty"`
+	GroupFontSize float64 `json:"groupFontSize,omitempty"`
+	NodeWidth     float64 `json:"nodeWidth,omitempty"`
+	// Background is the background of the whole diagram.
+	Background string `json:"background,omitempty"`
+	// FontColor is the color of the labels of the diagram, its groups, its edges and its nodes without background.
+	FontColor string `json:"fontColor,omitempty"`
+	// EdgeColor is the color of the edges not colored by their meaning.
+	EdgeColor      string `json:"edgeColor,omitempty"`
+	NamespaceColor string `json:"namespaceColor,omitempty"`
+	// RemoteNamespaceColor is the background of the namespaces of the services referenced from the diagram namespace.
+	RemoteNamespaceColor string `json:"remoteNamespaceColor,omitempty"`
+	ReleaseColor         string `json:"releaseColor,omitempty"`
+	LabelGroupColor      string `json:"labelGroupColor,omitempty"`
+	// SetColor is the background of the groups of the daemonSets, replicaSets and statefulSets with their pods.
+	SetColor string `json:"setColor,omitempty"`
+	// Grayscale turns the colors and icons of the diagram into shades of grey, for printing, when set to true.
+	Grayscale *bool `json:"grayscale,omitempty"`
+	// Kinds are the styles of the nodes of some kinds, such as Deployment or Service.
+	Kinds map[string]NodeStyle `json:"kinds,omitempty"`
+	// Rules are the styles of the nodes of the objects having some labels, applied in order over the style of their
+	// kind.
+	Rules []StyleRule `json:"rules,omitempty"`
+}
+
+// NodeStyle is the style of some nodes. Their background is the one of their health when they have one.
+type NodeStyle struct {
+	Color     string  `json:"color,omitempty"`
+	FontColor string  `json:"fontColor,omitempty"`
+	FontName  string  `json:"fontName,omitempty"`
+	FontSize  float64 `json:"fontSize,omitempty"`
+	// Shape is the Graphviz shape drawn around the icon, such as box.
+	Shape string `json:"shape,omitempty"`
+	// Icon is the path of a PNG or SVG file replacing the icon of the nodes. SVG icons are only drawn in SVG images.
+	Icon string `json:"icon,omitempty"`
+}
+
+// StyleRule is the style of the nodes of the objects having all of its labels, and one of its kinds when it has some.
+type StyleRule struct {
+	Kinds  []string          `json:"kinds,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	NodeStyle
+}
+
+// DefaultStyle returns the style diagrams are drawn with unless WithStyle overrides it.
+func DefaultStyle() Style {
+	return Style{
+		Direction:            "TB",
+		NodeSep:              1,
+		RankSep:              0.75,
+		Splines:              "curved",
+		FontSize:             10,
+		GroupFontSize:        10,
+		NodeWidth:            1.2,
+		NamespaceColor:       "#E0ECF4",
+		RemoteNamespaceColor: "#F4F6F7",
+		ReleaseColor:         "#C6DBEF",
+		LabelGroupColor:      "#D0D1E6",
+		SetColor:             "#9EBCDA",
+	}
+}
+
+// WithStyle overrides the style of the diagram with the non-empty fields of a style. Its rules are applied after the
+// ones of the style it overrides.
+func WithStyle(s Style) Option {
+	return func(d *Diagram) {
+		d.style = d.style.merge(s)
+	}
+}
+
+func (s Style) merge(o Style) Style {
+	overrideString(&s.Direction, o.Direction)
+	overrideString(&s.Splines, o.Splines)
+	overrideString(&s.Background, o.Background)
+	overrideString(&s.FontColor, o.FontColor)
+	overrideString(&s.EdgeColor, o.EdgeColor)
+	overrideString(&s.NamespaceColor, o.NamespaceColor)
+	overrideString(&s.RemoteNamespaceColor, o.RemoteNamespaceColor)
+	overrideString(&s.ReleaseColor, o.ReleaseColor)
+	overrideString(&s.LabelGroupColor, o.LabelGroupColor)
+	overrideString(&s.SetColor, o.SetColor)
+	overrideFloat(&s.NodeSep, o.NodeSep)
+	overrideFloat(&s.RankSep, o.RankSep)
+	overrideFloat(&s.FontSize, o.FontSize)
+	overrideFloat(&s.GroupFontSize, o.GroupFontSize)
+	overrideFloat(&s.NodeWidth, o.NodeWidth)
+
+	overrideBool(&s.Grayscale, o.Grayscale)
+	overrideBool(&s.Layered, o.Layered)
+
+	if len(o.Kinds) > 0 {
+		kinds := make(map[string]NodeStyle, len(s.Kinds)+len(o.Kinds))
+
+		for kind, style := range s.Kinds {
+			kinds[kind] = style
+		}
+
+		for kind, style := range o.Kinds {
+			kinds[kind] = style
+		}
+
+		s.Kinds = kinds
+	}
+
+	s.Rules = append(append([]StyleRule(nil), s.Rules...), o.Rules...)
+
+	return s
+}
+
+func overrideString(s *string, o string) {
+	if o != "" {
+		*s = o
+	}
+}
+
+func overrideFloat(f *float64, o float64) {
+	if o != 0 {
+		*f = o
+	}
+}
+
+// overrideBool overrides a boolean set, to false as well as to true.
+func overrideBool(b **bool, o *bool) {
+	if o != nil {
+		*b = o
+	}
+}
+
+// Bool returns a pointer to a boolean, to set the Layered and Grayscale fields of a style.
+func Bool(b bool) *bool {
+	return &b
+}
+
+// enabled tells if a boolean of a style is set to true.
+func enabled(b *bool) bool {
+	return b != nil && *b
+}
+
+// labelObject records the labels of the object a node draws, to match the style rules.
+func (d *Diagram) labelObject(n *diagram.Node, labels map[string]string) {
+	d.objectLabels[d.keys[n.ID()]] = labels
+}
+
+// applyStyle applies the styles of their kinds and labels to the nodes, and the colors of the style to the edges,
+// once everything is drawn.
+func (d *Diagram) applyStyle() {
+	for key, nodes := range d.keyNodes {
+		kind, labels := d.graphNodes[key].Kind, d.objectLabels[key]
+
+		style := d.style.Kinds[kind]
+
+		for _, r := range d.style.Rules {
+			if r.matches(kind, labels) {
+				style = style.merge(r.NodeStyle)
+			}
+		}
+
+		for _, n := range nodes {
+			d.styleNode(n, style)
+
+			unstyled := n.Options.Font.Color == "" || n.Options.Font.Color == defaultFontColor
+			if d.style.FontColor != "" && unstyled && n.Options.Attributes["fillcolor"] == "" {
+				n.Options.Font.Color = d.style.FontColor
+			}
+		}
+	}
+
+	for _, e := range d.allEdges() {
+		if d.style.EdgeColor != "" && e.Options.Color == defaultEdgeColor {
+			e.Options.Color = d.style.EdgeColor
+		}
+
+		if d.style.FontColor != "" && e.Options.Font.Color == defaultFontColor {
+			e.Options.Font.Color = d.style.FontColor
+		}
+	}
+}
+
+func (s NodeStyle) merge(o NodeStyle) NodeStyle {
+	overrideString(&s.Color, o.Color)
+	overrideString(&s.FontColor, o.FontColor)
+	overrideString(&s.FontName, o.FontName)
+	overrideFloat(&s.FontSize, o.FontSize)
+	overrideString(&s.Shape, o.Shape)
+	overrideString(&s.Icon, o.Icon)
+
+	return s
+}
+
+func (r StyleRule) matches(kind string, labels map[string]string) bool {
+	if len(r.Kinds) > 0 && !contains(r.Kinds, kind) {
+		return false
+	}
+
+	for k, v := range r.Labels {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// styleNode applies a style to a node.
+func (d *Diagram) styleNode(n *diagram.Node, s NodeStyle) {
+	if s.Color != "" && n.Options.Attributes["fillcolor"] == "" {
+		n.Options.Attributes["style"] = "filled"
+		n.Options.Attributes["fillcolor"] = s.Color
+	}
+
+	if s.FontColor != "" {
+		n.Options.Font.Color = s.FontColor
+	}
+
+	if s.FontName != "" {
+		n.Options.Font.Name = s.FontName
+	}
+
+	if s.FontSize != 0 {
+		n.Options.Font.Size = s.FontSize
+	}
+
+	if s.Shape != "" {
+		n.Options.Attributes["shape"] = s.Shape
+	}
+
+	if s.Icon != "" {
+		n.Options.Attributes["image"] = d.icon(s.Icon)
+	}
+}
+
+// icon returns the path of a custom icon, relative to the output directory it is copied to when rendering.
+func (d *Diagram) icon(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	rel := fmt.Sprintf("icons/%x-%s", sum[:4], filepath.Base(path))
+
+	d.icons[path] = rel
+
+	return rel
+}
+
+// formatFloat writes a size as Graphviz attributes expect it.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
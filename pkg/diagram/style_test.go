@@ -0,0 +1,180 @@
+package diagram
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+func TestWithStyle(t *testing.T) {
+	d, err := NewTempDiagram("k8s", "Kubernetes", WithStyle(Style{
+		NamespaceColor: "#FFFFFF",
+		Kinds:          map[string]NodeStyle{"Service": {Color: "#000000", FontColor: "#FFFFFF", FontSize: 12}},
+	}))
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, webService()))
+
+	if d.style.Direction != "TB" || d.style.FontSize != 10 {
+		t.Errorf("defaults not kept: %+v", d.style)
+	}
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{"rankdir=TB", `bgcolor="#FFFFFF"`} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT source doesn't contain %s:\n%s", want, dot.String())
+		}
+	}
+
+	n := d.services["web"]
+	if got := n.Options.Attributes["fillcolor"]; got != "#000000" {
+		t.Errorf("service fill color = %s, want #000000", got)
+	}
+
+	if n.Options.Font.Color != "#FFFFFF" || n.Options.Font.Size != 12 {
+		t.Errorf("service font = %+v, want #FFFFFF 12", n.Options.Font)
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	d, err := NewDiagram(t.TempDir(), "k8s", "Kubernetes", WithFilter(nil, []discovery.Rule{{Kinds: []string{"Service"}}}))
+	if err != nil {
+		t.Fatalf("NewDiagram() error = %v", err)
+	}
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, webService()))
+
+	if len(d.services) != 0 {
+		t.Errorf("got %d services, want them excluded", len(d.services))
+	}
+}
+
+func TestStyleRules(t *testing.T) {
+	icon := filepath.Join(t.TempDir(), "gateway.png")
+
+	f, err := os.Create(icon)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	f.Close()
+
+	d, err := NewTempDiagram("k8s", "Kubernetes", WithStyle(Style{
+		Kinds: map[string]NodeStyle{"Service": {Color: "#000000", FontSize: 12}},
+		Rules: []StyleRule{{
+			Labels:    map[string]string{"tier": "edge"},
+			NodeStyle: NodeStyle{Color: "#111111", Shape: "box", Icon: icon},
+		}},
+	}))
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	svc := webService()
+	svc.Labels = map[string]string{"tier": "edge"}
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, svc))
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	n := d.services["web"]
+	if got := n.Options.Attributes["fillcolor"]; got != "#111111" {
+		t.Errorf("service fill color = %s, want the color of the rule", got)
+	}
+
+	if n.Options.Font.Size != 12 || n.Options.Attributes["shape"] != "box" {
+		t.Errorf("service style = %+v, %v, want font size 12 and box shape", n.Options.Font, n.Options.Attributes)
+	}
+
+	ref := n.Options.Attributes["image"]
+	if !strings.Contains(dot.String(), `image="`+ref+`"`) {
+		t.Errorf("DOT source doesn't reference the icon %s:\n%s", ref, dot.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(d.outputDir, ref)); err != nil {
+		t.Errorf("icon not copied to the output directory: %v", err)
+	}
+}
+
+func TestTheme(t *testing.T) {
+	if _, err := Theme("neon"); err == nil {
+		t.Error("Theme() succeeded with an unknown theme")
+	}
+
+	monochrome, err := Theme("monochrome")
+	if err != nil {
+		t.Fatalf("Theme() error = %v", err)
+	}
+
+	d, err := NewTempDiagram("k8s", "Kubernetes", WithStyle(monochrome))
+	if err != nil {
+		t.Fatalf("NewTempDiagram() error = %v", err)
+	}
+	defer d.Close()
+
+	d.GenerateDiagram(testNamespace, discoverFakeCluster(t, webService()))
+
+	var dot bytes.Buffer
+	if err := d.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, c := range hexColor.FindAllString(dot.String(), -1) {
+		if c[1:3] != c[3:5] || c[3:5] != c[5:7] {
+			t.Errorf("color %s isn't a shade of grey", c)
+		}
+	}
+}
+
+func TestStyleMergeBooleans(t *testing.T) {
+	tests := []struct {
+		name    string
+		styles  []Style
+		layered bool
+		gray    bool
+	}{
+		{name: "unset", styles: []Style{{}}},
+		{name: "set", styles: []Style{{Layered: Bool(true), Grayscale: Bool(true)}}, layered: true, gray: true},
+		{
+			name:    "kept",
+			styles:  []Style{{Layered: Bool(true), Grayscale: Bool(true)}, {}},
+			layered: true,
+			gray:    true,
+		},
+		{
+			name:   "turned off",
+			styles: []Style{{Layered: Bool(true), Grayscale: Bool(true)}, {Layered: Bool(false), Grayscale: Bool(false)}},
+		},
+	}
+
+	for _, test := range tests {
+		var s Style
+		for _, o := range test.styles {
+			s = s.merge(o)
+		}
+
+		if enabled(s.Layered) != test.layered || enabled(s.Grayscale) != test.gray {
+			t.Errorf("%s: got layered %t and grayscale %t, want %t and %t",
+				test.name, enabled(s.Layered), enabled(s.Grayscale), test.layered, test.gray)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package diagram
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// themes are the built-in styles, by name.
+var themes = map[string]func() Style{
+	"default": func() Style {
+		return Style{}
+	},
+	"dark": func() Style {
+		return Style{
+			Background:           "#1E272E",
+			FontColor:            "#ECF0F1",
+			EdgeColor:            "#95A5A6",
+			NamespaceColor:       "#2C3E50",
+			RemoteNamespaceColor: "#273746",
+			ReleaseColor:         "#34495E",
+			LabelGroupColor:      "#3B4F63",
+			SetColor:             "#415B76",
+		}
+	},
+	"monochrome": func() Style {
+		return Style{Background: "#FFFFFF", Grayscale: Bool(true)}
+	},
+}
+
+// Themes returns the names of the built-in themes.
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Theme returns the style of a built-in theme, to be given to WithStyle: default, dark for slides, or monochrome for
+// printing.
+func Theme(name string) (Style, error) {
+	theme, ok := themes[name]
+	if !ok {
+		return Style{}, fmt.Errorf("unknown theme %q, want one of %s", name, strings.Join(Themes(), ", "))
+	}
+
+	return theme(), nil
+}
+
+// hexColor matches the colors of the DOT source.
+var hexColor = regexp.MustCompile(`#[0-9A-Fa-f]{6}\b`)
+
+// gray returns the shade of grey of a #RRGGBB color, of the same luminance.
+func gray(hex string) string {
+	rgb, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return hex
+	}
+
+	y := color.GrayModel.Convert(color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xFF}).(color.Gray).Y
+
+	return fmt.Sprintf("#%02X%02X%02X", y, y, y)
+}
+
+// grayscale turns the colors of the DOT source and the PNG icons of the output directory into shades of grey.
+func (d *Diagram) grayscale() error {
+	d.dot = hexColor.ReplaceAllFunc(d.dot, func(c []byte) []byte {
+		return []byte(gray(string(c)))
+	})
+
+	return filepath.Walk(d.outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".png") {
+			return err
+		}
+
+		return grayscaleImage(path)
+	})
+}
+
+func grayscaleImage(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading icon: %w", err)
+	}
+
+	img, err := png.Decode(f)
+	f.Close()
+
+	if err != nil {
+		return fmt.Errorf("decoding icon %s: %w", path, err)
+	}
+
+	// The alpha channel is kept, for the icons to stay transparent around their shapes.
+	bounds := img.Bounds()
+	grey := image.NewNRGBA(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			l := color.GrayModel.Convert(color.RGBA{R: c.R, G: c.G, B: c.B, A: 0xFF}).(color.Gray).Y
+			grey.SetNRGBA(x, y, color.NRGBA{R: l, G: l, B: l, A: c.A})
+		}
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("writing icon: %w", err)
+	}
+
+	if err := png.Encode(out, grey); err != nil {
+		out.Close()
+
+		return fmt.Errorf("encoding icon %s: %w", path, err)
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("writing icon: %w", err)
+	}
+
+	return nil
+}
+
+// copyIcons copies the custom icons of the styles to the output directory, where the DOT source references them.
+func (d *Diagram) copyIcons() error {
+	for path, rel := range d.icons {
+		if err := copyFile(path, filepath.Join(d.outputDir, filepath.FromSlash(rel))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package diagram
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/blushft/go-diagrams/diagram"
+	"github.com/trois-six/k8s-diagrams/pkg/metrics"
+)
+
+const (
+	trafficErrorColor     = "#C0392B"
+	trafficErrorThreshold = 0.05
+	maxTrafficPenWidth    = 8
+)
+
+// ShowTraffic labels the links from the ingresses to their services, and from the services to their pods, with the
+// request rate, error rate and latency flowing through them, and draws them thicker as their request rate grows. The
+// links failing more than 5% of their requests are drawn in red.
+func (d *Diagram) ShowTraffic(t metrics.Traffic) {
+	names := func(nodes map[string]*diagram.Node) map[string]string {
+		byID := make(map[string]string, len(nodes))
+		for name, n := range nodes {
+			byID[n.ID()] = name
+		}
+
+		return byID
+	}
+
+	ingresses, services, pods := names(d.ingresses), names(d.services), names(d.pods)
+
+	for _, e := range d.allEdges() {
+		var (
+			stats metrics.Stats
+			ok    bool
+		)
+
+		switch {
+		case ingresses[e.Start()] != "" && services[e.End()] != "":
+			stats, ok = t.Ingresses[metrics.Link{From: ingresses[e.Start()], To: services[e.End()]}]
+		case pods[e.Start()] != "" && services[e.End()] != "":
+			// Services are linked to their pods with reversed edges.
+			stats, ok = t.Services[metrics.Link{From: services[e.End()], To: pods[e.Start()]}]
+		}
+
+		if !ok {
+			continue
+		}
+
+		label := trafficLabel(stats)
+		if e.Options.Label != "" {
+			label = e.Options.Label + "\\n" + label
+		}
+
+		edgeLabel(label)(&e.Options)
+		e.Options.Attributes["penwidth"] = strconv.FormatFloat(trafficPenWidth(stats.RequestRate), 'f', 1, 64)
+
+		if stats.RequestRate > 0 && stats.ErrorRate/stats.RequestRate > trafficErrorThreshold {
+			e.Options.Color = trafficErrorColor
+		}
+	}
+}
+
+// trafficLabel summarizes the traffic of a link, as in 12.5 req/s, 0.4% errors, p95 35ms.
+func trafficLabel(s metrics.Stats) string {
+	format := "%.1f req/s"
+	if s.RequestRate > 0 && s.RequestRate < 0.1 {
+		format = "%.2g req/s"
+	}
+
+	label := fmt.Sprintf(format, s.RequestRate)
+
+	if s.RequestRate > 0 {
+		label += fmt.Sprintf(", %.1f%% errors", 100*s.ErrorRate/s.RequestRate)
+	}
+
+	switch {
+	case s.Latency >= time.Millisecond:
+		label += ", p95 " + s.Latency.Round(time.Millisecond).String()
+	case s.Latency > 0:
+		label += ", p95 " + s.Latency.Round(time.Microsecond).String()
+	}
+
+	return label
+}
+
+// trafficPenWidth grows the width of an edge with the logarithm of its request rate.
+func trafficPenWidth(rate float64) float64 {
+	return math.Min(1+2*math.Log10(1+rate), maxTrafficPenWidth)
+}
@@ -0,0 +1,79 @@
+package diagram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trois-six/k8s-diagrams/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShowTraffic(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+
+	pod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: selector}}
+	}
+
+	svc := webService()
+	svc.Spec.Selector = selector
+
+	d := generateFromFakeCluster(t, svc, pod("web-1"), pod("web-2"))
+
+	d.ShowTraffic(metrics.Traffic{Services: map[metrics.Link]metrics.Stats{
+		{From: "web", To: "web-1"}: {RequestRate: 12.5, ErrorRate: 1.25, Latency: 35 * time.Millisecond},
+	}})
+
+	for _, e := range d.namespaceGroups[testNamespace].Edges() {
+		switch e.Start() {
+		case d.pods["web-1"].ID():
+			if want := "12.5 req/s, 10.0% errors, p95 35ms"; e.Options.Label != want {
+				t.Errorf("got label %q, want %q", e.Options.Label, want)
+			}
+
+			if e.Options.Color != trafficErrorColor || e.Options.Attributes["penwidth"] != "3.3" {
+				t.Errorf("got color %q and width %q, want a red and thick edge",
+					e.Options.Color, e.Options.Attributes["penwidth"])
+			}
+		case d.pods["web-2"].ID():
+			if _, ok := e.Options.Attributes["penwidth"]; ok {
+				t.Error("got a width on an edge without traffic")
+			}
+		}
+	}
+}
+
+func TestShowTrafficRedacted(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+
+	svc := webService()
+	svc.Spec.Selector = selector
+
+	d, r := generateRedacted(t, discoverFakeCluster(t, svc, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: testNamespace, Labels: selector},
+	}))
+
+	traffic := metrics.Traffic{Services: map[metrics.Link]metrics.Stats{
+		{From: "web", To: "web-1"}: {RequestRate: 2},
+	}}
+
+	d.ShowTraffic(traffic.Rename(r.Alias))
+
+	pod, ok := d.pods[r.Alias("web-1")]
+	if !ok || r.Alias("web-1") == "web-1" {
+		t.Fatalf("redacted pod not found in %v", d.pods)
+	}
+
+	labels := 0
+
+	for _, e := range d.namespaceGroups[r.Alias(testNamespace)].Edges() {
+		if e.Start() == pod.ID() && e.Options.Label == "2.0 req/s, 0.0% errors" {
+			labels++
+		}
+	}
+
+	if labels != 1 {
+		t.Errorf("got %d edges labeled with the traffic of the redacted pod, want 1", labels)
+	}
+}
@@ -34,9 +34,9 @@ func addServiceFromV1Beta1(ing *networkingv1.Ingress, old networkingv1beta1.Ingr
 		}
 
 		for pc, path := range rule.HTTP.Paths {
-			if path.Backend.Service == nil {
-				oldBackend := old.Spec.Rules[rc].HTTP.Paths[pc].Backend
+			oldBackend := old.Spec.Rules[rc].HTTP.Paths[pc].Backend
 
+			if path.Backend.Service == nil && oldBackend.ServiceName != "" {
 				port := networkingv1.ServiceBackendPort{}
 				if oldBackend.ServicePort.Type == intstr.Int {
 					port.Number = oldBackend.ServicePort.IntVal
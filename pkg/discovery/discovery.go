@@ -3,77 +3,107 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/go-version"
+	"github.com/rs/zerolog/log"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
 type Objects struct {
-	Version                *version.Version
+	Version                *version.Version `json:"-"`
 	ConfigMaps             *corev1.ConfigMapList
 	Endpoints              *corev1.EndpointsList
+	EndpointSlices         *discoveryv1.EndpointSliceList
 	Namespaces             *corev1.NamespaceList
 	Pods                   *corev1.PodList
 	PersistentVolumes      *corev1.PersistentVolumeList
 	PersistentVolumeClaims *corev1.PersistentVolumeClaimList
 	Secrets                *corev1.SecretList
 	Services               *corev1.ServiceList
+	ReferencedServices     *corev1.ServiceList
 	DaemonSets             *appsv1.DaemonSetList
 	Deployments            *appsv1.DeploymentList
 	ReplicaSets            *appsv1.ReplicaSetList
 	StatefulSets           *appsv1.StatefulSetList
 	Ingresses              *networkingv1.IngressList
+	ServiceAccounts        *corev1.ServiceAccountList
+	Roles                  *rbacv1.RoleList
+	RoleBindings           *rbacv1.RoleBindingList
+	ClusterRoles           *rbacv1.ClusterRoleList
+	ClusterRoleBindings    *rbacv1.ClusterRoleBindingList
+	HelmReleases           []HelmRelease
 }
 
 type Discovery struct {
-	client  *kubernetes.Clientset
-	ctx     context.Context
-	objects *Objects
+	client        kubernetes.Interface
+	ctx           context.Context
+	objects       *Objects
+	rbac          bool
+	helmReleases  bool
+	clusterDomain string
 }
 
-// NewDiscovery initialize a discovery of k8s objects.
-func NewDiscovery(ctx context.Context, config *rest.Config) (Discovery, error) {
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		err = fmt.Errorf("creating kubernetes client: %w", err)
-	}
+// Option configures a Discovery.
+type Option func(*Discovery)
 
-	return Discovery{
-		client:  clientset,
-		ctx:     ctx,
-		objects: &Objects{},
-	}, err
+// WithRBAC also discovers the service accounts, roles and role bindings of the namespace, and the cluster roles and
+// cluster role bindings granting permissions to its service accounts.
+func WithRBAC(enabled bool) Option {
+	return func(k *Discovery) {
+		k.rbac = enabled
+	}
 }
 
-// func (k *Discovery) generateSecrets(namespace string) error {
-// 	secrets, err := k.client.CoreV1().Secrets(namespace).List(k.ctx, metav1.ListOptions{})
-// 	if err != nil {
-// 		return fmt.Errorf("getting secrets: %w", err)
-// 	}
+// WithHelmReleases also reads the chart metadata of the Helm releases of the namespace from the secrets Helm stores
+// them in.
+func WithHelmReleases(enabled bool) Option {
+	return func(k *Discovery) {
+		k.helmReleases = enabled
+	}
+}
 
-// 	filteredSecrets := &corev1.SecretList{}
+// WithClusterDomain sets the domain the services of the cluster are named in, to tell the ExternalName services
+// pointing to services of other namespaces. It defaults to DefaultClusterDomain.
+func WithClusterDomain(domain string) Option {
+	return func(k *Discovery) {
+		k.clusterDomain = domain
+	}
+}
 
-// 	for _, secret := range secrets.Items {
-// 		if !strings.HasPrefix(secret.ObjectMeta.Name, "sh.helm.release") {
-// 			if secret.ObjectMeta.Annotations["kubectl.kubernetes.io/last-applied-configuration"] != "" {
-// 				delete(secret.ObjectMeta.Annotations, "kubectl.kubernetes.io/last-applied-configuration")
-// 			}
+// NewDiscovery initialize a discovery of k8s objects.
+func NewDiscovery(ctx context.Context, config *rest.Config, opts ...Option) (Discovery, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return Discovery{}, fmt.Errorf("creating kubernetes client: %w", err)
+	}
 
-// 			secret.Data = make(map[string][]byte)
+	return NewDiscoveryFromClient(ctx, clientset, opts...), nil
+}
 
-// 			filteredSecrets.Items = append(filteredSecrets.Items, secret)
-// 		}
-// 	}
+// NewDiscoveryFromClient initialize a discovery of k8s objects using an existing client.
+func NewDiscoveryFromClient(ctx context.Context, client kubernetes.Interface, opts ...Option) Discovery {
+	k := Discovery{
+		client:        client,
+		ctx:           ctx,
+		objects:       &Objects{},
+		clusterDomain: DefaultClusterDomain,
+	}
 
-// 	k.objects.Secrets = filteredSecrets
+	for _, opt := range opts {
+		opt(&k)
+	}
 
-// 	return nil
-// }
+	return k
+}
 
 func (k *Discovery) generateCore(namespace string) error {
 	ns, err := k.client.CoreV1().Namespaces().List(k.ctx, metav1.ListOptions{})
@@ -83,6 +113,10 @@ func (k *Discovery) generateCore(namespace string) error {
 
 	k.objects.Namespaces = ns
 
+	if !k.objects.HasNamespace(namespace) {
+		return &Error{Reason: ErrNamespaceNotFound, Err: fmt.Errorf("namespace %s not found", namespace)}
+	}
+
 	// cm, err := k.client.CoreV1().ConfigMaps(namespace).List(k.ctx, metav1.ListOptions{})
 	// if err != nil {
 	// 	return fmt.Errorf("getting configmaps: %w", err)
@@ -111,16 +145,12 @@ func (k *Discovery) generateCore(namespace string) error {
 
 	// k.objects.PersistentVolumes = pv
 
-	// pvc, err := k.client.CoreV1().PersistentVolumeClaims(namespace).List(k.ctx, metav1.ListOptions{})
-	// if err != nil {
-	// 	return fmt.Errorf("getting persitent volumes claims: %w", err)
-	// }
-
-	// k.objects.PersistentVolumeClaims = pvc
+	pvc, err := k.client.CoreV1().PersistentVolumeClaims(namespace).List(k.ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("getting persitent volumes claims: %w", err)
+	}
 
-	// if err = k.generateSecrets(namespace); err != nil {
-	// 	return err
-	// }
+	k.objects.PersistentVolumeClaims = pvc
 
 	svc, err := k.client.CoreV1().Services(namespace).List(k.ctx, metav1.ListOptions{})
 	if err != nil {
@@ -194,11 +224,122 @@ func (k *Discovery) generateNetworking(namespace string) error {
 		k.objects.Ingresses = ing
 	}
 
+	return k.generateEndpointSlices(namespace)
+}
+
+// generateEndpointSlices gets the EndpointSlices of a namespace when the server serves them, as Endpoints are
+// truncated at 1000 addresses. The Endpoints are used when they are not served, or can't be read.
+func (k *Discovery) generateEndpointSlices(namespace string) error {
+	groupVersion := discoveryv1.SchemeGroupVersion.String()
+
+	resources, err := k.client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil || !servesResource(resources, "endpointslices") {
+		log.Debug().Err(err).Msgf("EndpointSlices are not served in %s, using Endpoints", groupVersion)
+
+		return nil
+	}
+
+	eps, err := k.client.DiscoveryV1().EndpointSlices(namespace).List(k.ctx, metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		log.Warn().Err(err).Msg("EndpointSlices can't be read, using Endpoints")
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting endpointslices: %w", err)
+	}
+
+	k.objects.EndpointSlices = eps
+
 	return nil
 }
 
-// GenerateAll gets all kubernetes objects.
+// servesResource tells if a list of the resources of a group version has a resource.
+func servesResource(resources *metav1.APIResourceList, name string) bool {
+	if resources == nil {
+		return false
+	}
+
+	for _, r := range resources.APIResources {
+		if r.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultClusterDomain is the domain the services of a cluster are named in, unless the cluster is configured with
+// another one.
+const DefaultClusterDomain = "cluster.local"
+
+// ServiceReference tells if the external name of a service points to an in-cluster service, written as
+// <service>.<namespace>.svc or <service>.<namespace>.svc.<cluster domain>, and returns its name and namespace. An empty
+// cluster domain stands for DefaultClusterDomain.
+func ServiceReference(externalName, clusterDomain string) (name, namespace string, ok bool) {
+	if clusterDomain == "" {
+		clusterDomain = DefaultClusterDomain
+	}
+
+	parts := strings.SplitN(strings.TrimSuffix(externalName, "."), ".", 4)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] != "svc" {
+		return "", "", false
+	}
+
+	if len(parts) == 4 && parts[3] != strings.Trim(clusterDomain, ".") {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// generateExternalReferences gets the services of other namespaces that ExternalName services point to, kept apart
+// from the services of the namespace.
+func (k *Discovery) generateExternalReferences(namespace string) error {
+	for _, svc := range k.objects.Services.Items {
+		if svc.Spec.Type != corev1.ServiceTypeExternalName {
+			continue
+		}
+
+		name, ns, ok := ServiceReference(svc.Spec.ExternalName, k.clusterDomain)
+		if !ok || ns == namespace {
+			continue
+		}
+
+		ref, err := k.client.CoreV1().Services(ns).Get(k.ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			log.Warn().Err(err).Msgf("Service %s referenced by %s can't be read", svc.Spec.ExternalName, svc.Name)
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("getting service %s/%s: %w", ns, name, err)
+		}
+
+		if k.objects.ReferencedServices == nil {
+			k.objects.ReferencedServices = &corev1.ServiceList{}
+		}
+
+		k.objects.ReferencedServices.Items = append(k.objects.ReferencedServices.Items, *ref)
+	}
+
+	return nil
+}
+
+// GenerateAll gets all kubernetes objects. Its errors tell why it failed with errors.Is, such as ErrUnauthorized,
+// ErrForbidden or ErrNamespaceNotFound.
 func (k *Discovery) GenerateAll(namespace string) (*Objects, error) {
+	o, err := k.generateAll(namespace)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	return o, nil
+}
+
+func (k *Discovery) generateAll(namespace string) (*Objects, error) {
 	serverVersion, err := k.client.Discovery().ServerVersion()
 	if err != nil {
 		return nil, fmt.Errorf("getting server version: %w", err)
@@ -221,5 +362,21 @@ func (k *Discovery) GenerateAll(namespace string) (*Objects, error) {
 		return nil, err
 	}
 
+	if err := k.generateExternalReferences(namespace); err != nil {
+		return nil, err
+	}
+
+	if k.rbac {
+		if err := k.generateRBAC(namespace); err != nil {
+			return nil, err
+		}
+	}
+
+	if k.helmReleases {
+		if err := k.generateHelmReleases(namespace); err != nil {
+			return nil, err
+		}
+	}
+
 	return k.objects, nil
 }
@@ -0,0 +1,256 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNamespace = "app"
+
+func newFakeDiscovery(t *testing.T, gitVersion string, objects ...runtime.Object) Discovery {
+	t.Helper()
+
+	objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+
+	client := fake.NewSimpleClientset(objects...)
+	client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: gitVersion}
+
+	return NewDiscoveryFromClient(context.Background(), client)
+}
+
+func TestGenerateAllIngressesV1(t *testing.T) {
+	apiGroup := "storage.example.com"
+
+	k := newFakeDiscovery(t, "v1.21.0", &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: testNamespace},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{Host: "host-only.example.com"},
+				{
+					Host: "bucket.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path: "/static",
+							Backend: networkingv1.IngressBackend{
+								Resource: &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "Bucket", Name: "assets"},
+							},
+						}},
+					}},
+				},
+			},
+		},
+	})
+
+	o, err := k.GenerateAll(testNamespace)
+	if err != nil {
+		t.Fatalf("GenerateAll() error = %v", err)
+	}
+
+	if len(o.Ingresses.Items) != 1 {
+		t.Fatalf("got %d ingresses, want 1", len(o.Ingresses.Items))
+	}
+
+	rules := o.Ingresses.Items[0].Spec.Rules
+	if rules[0].HTTP != nil {
+		t.Errorf("host-only rule got HTTP paths: %v", rules[0].HTTP)
+	}
+
+	if backend := rules[1].HTTP.Paths[0].Backend; backend.Resource == nil || backend.Service != nil {
+		t.Errorf("got backend %+v, want the resource backend only", backend)
+	}
+}
+
+func TestGenerateAllIngressesV1Beta1(t *testing.T) {
+	k := newFakeDiscovery(t, "v1.18.0", &networkingv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: testNamespace},
+		Spec: networkingv1beta1.IngressSpec{
+			Backend: &networkingv1beta1.IngressBackend{
+				Resource: &corev1.TypedLocalObjectReference{Kind: "Bucket", Name: "fallback"},
+			},
+			Rules: []networkingv1beta1.IngressRule{
+				{Host: "host-only.example.com"},
+				{
+					Host: "web.example.com",
+					IngressRuleValue: networkingv1beta1.IngressRuleValue{HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+						Paths: []networkingv1beta1.HTTPIngressPath{
+							{
+								Path:    "/",
+								Backend: networkingv1beta1.IngressBackend{ServiceName: "web", ServicePort: intstr.FromString("http")},
+							},
+							{
+								Path: "/static",
+								Backend: networkingv1beta1.IngressBackend{
+									Resource: &corev1.TypedLocalObjectReference{Kind: "Bucket", Name: "assets"},
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	})
+
+	o, err := k.GenerateAll(testNamespace)
+	if err != nil {
+		t.Fatalf("GenerateAll() error = %v", err)
+	}
+
+	ing := o.Ingresses.Items[0]
+
+	if ing.Spec.DefaultBackend == nil || ing.Spec.DefaultBackend.Resource == nil || ing.Spec.DefaultBackend.Service != nil {
+		t.Errorf("got default backend %+v, want the resource backend only", ing.Spec.DefaultBackend)
+	}
+
+	paths := ing.Spec.Rules[1].HTTP.Paths
+
+	if svc := paths[0].Backend.Service; svc == nil || svc.Name != "web" || svc.Port.Name != "http" {
+		t.Errorf("got service backend %+v, want web:http", svc)
+	}
+
+	if backend := paths[1].Backend; backend.Resource == nil || backend.Service != nil {
+		t.Errorf("got backend %+v, want the resource backend only", backend)
+	}
+}
+
+func TestServiceReference(t *testing.T) {
+	tests := []struct {
+		externalName  string
+		clusterDomain string
+		name          string
+		namespace     string
+		ok            bool
+	}{
+		{externalName: "postgres.data.svc", name: "postgres", namespace: "data", ok: true},
+		{externalName: "postgres.data.svc.cluster.local", name: "postgres", namespace: "data", ok: true},
+		{externalName: "postgres.data.svc.cluster.local.", name: "postgres", namespace: "data", ok: true},
+		{
+			externalName:  "postgres.data.svc.corp.internal",
+			clusterDomain: "corp.internal",
+			name:          "postgres",
+			namespace:     "data",
+			ok:            true,
+		},
+		{externalName: "db.prod.svc.corp.net"},
+		{externalName: "postgres.data.svc.cluster.local", clusterDomain: "corp.internal"},
+		{externalName: "api.payments.example.com"},
+		{externalName: "postgres.data"},
+		{externalName: ".data.svc"},
+	}
+
+	for _, test := range tests {
+		name, namespace, ok := ServiceReference(test.externalName, test.clusterDomain)
+		if name != test.name || namespace != test.namespace || ok != test.ok {
+			t.Errorf("ServiceReference(%q, %q) = %s, %s, %t, want %s, %s, %t",
+				test.externalName, test.clusterDomain, name, namespace, ok, test.name, test.namespace, test.ok)
+		}
+	}
+}
+
+func TestGenerateAllReferencedServices(t *testing.T) {
+	externalName := func(name, externalName string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: externalName},
+		}
+	}
+
+	service := func(namespace, name string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+		externalName("db", "postgres.data.svc.corp.internal"),
+		externalName("legacy", "db.prod.svc.corp.net"),
+		service("data", "postgres"),
+		service("prod", "db"),
+	)
+	client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: "v1.21.0"}
+
+	k := NewDiscoveryFromClient(context.Background(), client, WithClusterDomain("corp.internal"))
+
+	o, err := k.GenerateAll(testNamespace)
+	if err != nil {
+		t.Fatalf("GenerateAll() error = %v", err)
+	}
+
+	for _, svc := range o.Services.Items {
+		if svc.Namespace != testNamespace {
+			t.Errorf("got service %s/%s among the services of the namespace", svc.Namespace, svc.Name)
+		}
+	}
+
+	if o.ReferencedServices == nil || len(o.ReferencedServices.Items) != 1 {
+		t.Fatalf("got referenced services %+v, want data/postgres only", o.ReferencedServices)
+	}
+
+	if svc := o.ReferencedServices.Items[0]; svc.Namespace != "data" || svc.Name != "postgres" {
+		t.Errorf("got referenced service %s/%s, want data/postgres", svc.Namespace, svc.Name)
+	}
+}
+
+func TestGenerateAllEndpointSlices(t *testing.T) {
+	endpointSlices := &metav1.APIResourceList{
+		GroupVersion: discoveryv1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "endpointslices"}},
+	}
+
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		err       error
+		want      bool
+	}{
+		{name: "served", resources: []*metav1.APIResourceList{endpointSlices}, want: true},
+		{name: "not served"},
+		{
+			name:      "forbidden",
+			resources: []*metav1.APIResourceList{endpointSlices},
+			err:       apierrors.NewForbidden(discoveryv1.Resource("endpointslices"), "", errors.New("no permission")),
+		},
+	}
+
+	for _, test := range tests {
+		client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+
+		fakeDiscovery := client.Discovery().(*fakediscovery.FakeDiscovery)
+		// Vendor builds of the served versions are pre-releases of them.
+		fakeDiscovery.FakedServerVersion = &k8sversion.Info{GitVersion: "v1.21.0-eks-1"}
+		fakeDiscovery.Resources = test.resources
+
+		if test.err != nil {
+			client.PrependReactor("list", "endpointslices", func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, test.err
+			})
+		}
+
+		k := NewDiscoveryFromClient(context.Background(), client)
+
+		o, err := k.GenerateAll(testNamespace)
+		if err != nil {
+			t.Fatalf("%s: GenerateAll() error = %v", test.name, err)
+		}
+
+		if got := o.EndpointSlices != nil; got != test.want {
+			t.Errorf("%s: got EndpointSlices %t, want %t", test.name, got, test.want)
+		}
+
+		if o.Endpoints == nil {
+			t.Errorf("%s: got no Endpoints", test.name)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package discovery
+
+import (
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	// ErrUnauthorized is returned when the cluster rejects the credentials of the kubeconfig.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when the credentials of the kubeconfig lack a permission.
+	ErrForbidden = errors.New("forbidden")
+	// ErrNamespaceNotFound is returned when the namespace to discover doesn't exist.
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+// Error is an error of the discovery, whose Reason tells callers why it failed with errors.Is, such as ErrForbidden.
+type Error struct {
+	Reason error
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error, such as the status returned by the Kubernetes API.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is tells if the error failed for a reason.
+func (e *Error) Is(target error) bool {
+	return target == e.Reason
+}
+
+// classify gives a reason to the errors of the Kubernetes API callers need to tell apart.
+func classify(err error) error {
+	switch {
+	case apierrors.IsUnauthorized(err):
+		return &Error{Reason: ErrUnauthorized, Err: err}
+	case apierrors.IsForbidden(err):
+		return &Error{Reason: ErrForbidden, Err: err}
+	default:
+		return err
+	}
+}
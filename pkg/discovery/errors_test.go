@@ -0,0 +1,66 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGenerateAllErrors(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+
+	tests := []struct {
+		name      string
+		namespace string
+		err       error
+		want      error
+	}{
+		{
+			name:      "missing namespace",
+			namespace: "missing",
+			want:      ErrNamespaceNotFound,
+		},
+		{
+			name:      "forbidden",
+			namespace: testNamespace,
+			err:       apierrors.NewForbidden(pods, "", errors.New("no permission")),
+			want:      ErrForbidden,
+		},
+		{
+			name:      "unauthorized",
+			namespace: testNamespace,
+			err:       apierrors.NewUnauthorized("expired token"),
+			want:      ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+			client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: "v1.21.0"}
+
+			if tt.err != nil {
+				client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.err
+				})
+			}
+
+			k := NewDiscoveryFromClient(context.Background(), client)
+
+			_, err := k.GenerateAll(tt.namespace)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("GenerateAll() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
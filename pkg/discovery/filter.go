@@ -0,0 +1,155 @@
+package discovery
+
+import (
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Rule selects objects by kind, name and labels. An object matches a rule when it matches all of its non-empty
+// fields: one of its kinds, such as Deployment, one of its name patterns, such as *-canary, and all of its labels.
+type Rule struct {
+	Kinds  []string          `json:"kinds,omitempty"`
+	Names  []string          `json:"names,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Matches tells if an object matches the rule.
+func (r Rule) Matches(kind string, o metav1.Object) bool {
+	if len(r.Kinds) > 0 && !contains(r.Kinds, kind) {
+		return false
+	}
+
+	if len(r.Names) > 0 && !matchesAny(r.Names, o.GetName()) {
+		return false
+	}
+
+	for k, v := range r.Labels {
+		if value, ok := o.GetLabels()[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String describes the rule, as in kinds Pod,Job names *-canary labels team=shop.
+func (r Rule) String() string {
+	var parts []string
+
+	if len(r.Kinds) > 0 {
+		parts = append(parts, "kinds "+strings.Join(r.Kinds, ","))
+	}
+
+	if len(r.Names) > 0 {
+		parts = append(parts, "names "+strings.Join(r.Names, ","))
+	}
+
+	if len(r.Labels) > 0 {
+		labels := make([]string, 0, len(r.Labels))
+		for k, v := range r.Labels {
+			labels = append(labels, k+"="+v)
+		}
+
+		sort.Strings(labels)
+		parts = append(parts, "labels "+strings.Join(labels, ","))
+	}
+
+	if len(parts) == 0 {
+		return "all objects"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(p, name); ok && err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Kind returns the kind of a typed object, such as Deployment, which objects read from the API don't carry.
+func Kind(obj runtime.Object) string {
+	return reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+}
+
+// Filter returns the objects matching one of the include rules, or all of them without include rules, and none of
+// the exclude rules. Namespaces are always kept.
+func (o *Objects) Filter(include, exclude []Rule) *Objects {
+	filtered := &Objects{
+		Version:            o.Version,
+		ConfigMaps:         o.ConfigMaps,
+		PersistentVolumes:  o.PersistentVolumes,
+		Secrets:            o.Secrets,
+		ReferencedServices: o.ReferencedServices,
+		HelmReleases:       o.HelmReleases,
+	}
+
+	for _, obj := range o.items() {
+		m, ok := obj.(metav1.Object)
+		if !ok {
+			continue
+		}
+
+		if _, namespace := obj.(*corev1.Namespace); namespace || keep(Kind(obj), m, include, exclude) {
+			filtered.add(obj)
+		}
+	}
+
+	// Whether these lists were discovered at all matters to the diagrams.
+	if o.EndpointSlices != nil && filtered.EndpointSlices == nil {
+		filtered.EndpointSlices = &discoveryv1.EndpointSliceList{}
+	}
+
+	if o.ServiceAccounts != nil && filtered.ServiceAccounts == nil {
+		filtered.ServiceAccounts = &corev1.ServiceAccountList{}
+	}
+
+	filtered.ensureLists()
+
+	return filtered
+}
+
+func keep(kind string, o metav1.Object, include, exclude []Rule) bool {
+	included := len(include) == 0
+
+	for _, r := range include {
+		if r.Matches(kind, o) {
+			included = true
+
+			break
+		}
+	}
+
+	if !included {
+		return false
+	}
+
+	for _, r := range exclude {
+		if r.Matches(kind, o) {
+			return false
+		}
+	}
+
+	return true
+}
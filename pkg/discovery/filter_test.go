@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFilter(t *testing.T) {
+	meta := func(name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels}
+	}
+
+	o := &Objects{
+		Namespaces: &corev1.NamespaceList{Items: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}}},
+		Deployments: &appsv1.DeploymentList{Items: []appsv1.Deployment{
+			{ObjectMeta: meta("web", map[string]string{"team": "shop"})},
+			{ObjectMeta: meta("web-canary", map[string]string{"team": "shop"})},
+			{ObjectMeta: meta("billing", map[string]string{"team": "finance"})},
+		}},
+		Services:        &corev1.ServiceList{Items: []corev1.Service{{ObjectMeta: meta("web", map[string]string{"team": "shop"})}}},
+		ServiceAccounts: &corev1.ServiceAccountList{Items: []corev1.ServiceAccount{{ObjectMeta: meta("default", nil)}}},
+	}
+
+	filtered := o.Filter(
+		[]Rule{{Labels: map[string]string{"team": "shop"}}},
+		[]Rule{{Kinds: []string{"Deployment"}, Names: []string{"*-canary"}}},
+	)
+
+	if len(filtered.Namespaces.Items) != 1 {
+		t.Errorf("got %d namespaces, want 1", len(filtered.Namespaces.Items))
+	}
+
+	if len(filtered.Deployments.Items) != 1 || filtered.Deployments.Items[0].Name != "web" {
+		t.Errorf("got deployments %v, want web", filtered.Deployments.Items)
+	}
+
+	if len(filtered.Services.Items) != 1 {
+		t.Errorf("got %d services, want 1", len(filtered.Services.Items))
+	}
+
+	if filtered.ServiceAccounts == nil || len(filtered.ServiceAccounts.Items) != 0 {
+		t.Errorf("got service accounts %v, want an empty list", filtered.ServiceAccounts)
+	}
+
+	if filtered.Roles == nil {
+		t.Error("roles list not kept")
+	}
+}
+
+func TestKind(t *testing.T) {
+	if got := Kind(&appsv1.StatefulSet{}); got != "StatefulSet" {
+		t.Errorf("Kind() = %s, want StatefulSet", got)
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	r := Rule{Kinds: []string{"Pod", "Job"}, Names: []string{"*-canary"}, Labels: map[string]string{"tier": "web", "team": "shop"}}
+
+	if got, want := r.String(), "kinds Pod,Job names *-canary labels team=shop,tier=web"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if got, want := (Rule{}).String(), "all objects"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package discovery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	helmReleasePrefix = "sh.helm.release.v1."
+	helmOwnerSelector = "owner=helm"
+	helmReleaseKey    = "release"
+	helmReleaseLabel  = "name"
+	helmRevisionLabel = "version"
+	helmStatusLabel   = "status"
+)
+
+// HelmRelease is the metadata of a Helm release, read from the secret Helm stores it in.
+type HelmRelease struct {
+	Name         string
+	Namespace    string
+	Revision     int
+	Status       string
+	Chart        string
+	ChartVersion string
+	AppVersion   string
+}
+
+// helmReleaseData is the part of a Helm release stored in its secret that we are interested in.
+type helmReleaseData struct {
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+// generateHelmReleases gets the metadata of the last revision of each Helm release of a namespace. Only the chart
+// metadata is kept, the rest of the release data, including its values, is dropped.
+func (k *Discovery) generateHelmReleases(namespace string) error {
+	secrets, err := k.client.CoreV1().Secrets(namespace).List(k.ctx, metav1.ListOptions{LabelSelector: helmOwnerSelector})
+	if apierrors.IsForbidden(err) {
+		log.Warn().Err(err).Msg("Helm releases metadata can't be read")
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting helm releases secrets: %w", err)
+	}
+
+	releases := make(map[string]HelmRelease)
+
+	for _, secret := range secrets.Items {
+		if !strings.HasPrefix(secret.Name, helmReleasePrefix) {
+			continue
+		}
+
+		release, err := helmRelease(secret)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Can't read Helm release from secret %s", secret.Name)
+
+			continue
+		}
+
+		if last, ok := releases[release.Name]; ok && last.Revision > release.Revision {
+			continue
+		}
+
+		releases[release.Name] = release
+	}
+
+	for _, release := range releases {
+		k.objects.HelmReleases = append(k.objects.HelmReleases, release)
+	}
+
+	sort.Slice(k.objects.HelmReleases, func(i, j int) bool {
+		return k.objects.HelmReleases[i].Name < k.objects.HelmReleases[j].Name
+	})
+
+	return nil
+}
+
+func helmRelease(secret corev1.Secret) (HelmRelease, error) {
+	revision, err := strconv.Atoi(secret.Labels[helmRevisionLabel])
+	if err != nil {
+		return HelmRelease{}, fmt.Errorf("parsing revision: %w", err)
+	}
+
+	release := HelmRelease{
+		Name:      secret.Labels[helmReleaseLabel],
+		Namespace: secret.Namespace,
+		Revision:  revision,
+		Status:    secret.Labels[helmStatusLabel],
+	}
+
+	data, err := decodeHelmRelease(secret.Data[helmReleaseKey])
+	if err != nil {
+		return HelmRelease{}, err
+	}
+
+	release.Chart = data.Chart.Metadata.Name
+	release.ChartVersion = data.Chart.Metadata.Version
+	release.AppVersion = data.Chart.Metadata.AppVersion
+
+	return release, nil
+}
+
+// decodeHelmRelease decodes a release the way Helm encodes it: gzipped JSON, base64 encoded.
+func decodeHelmRelease(raw []byte) (helmReleaseData, error) {
+	var data helmReleaseData
+
+	b, err := base64.StdEncoding.DecodeString(string(raw))
+	if err != nil {
+		return data, fmt.Errorf("decoding release: %w", err)
+	}
+
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return data, fmt.Errorf("decompressing release: %w", err)
+		}
+		defer r.Close()
+
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return data, fmt.Errorf("decompressing release: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(b, &data); err != nil {
+		return data, fmt.Errorf("unmarshaling release: %w", err)
+	}
+
+	return data, nil
+}
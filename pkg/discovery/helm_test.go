@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func helmSecret(t *testing.T, release string, revision int, status, chartVersion string) *corev1.Secret {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := fmt.Fprintf(w, `{"chart":{"metadata":{"name":"web","version":%q,"appVersion":"2.0"}}}`, chartVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s%s.v%d", helmReleasePrefix, release, revision),
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				"owner":           "helm",
+				helmReleaseLabel:  release,
+				helmRevisionLabel: fmt.Sprint(revision),
+				helmStatusLabel:   status,
+			},
+		},
+		Data: map[string][]byte{helmReleaseKey: []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))},
+	}
+}
+
+func TestGenerateAllHelmReleases(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+		helmSecret(t, "shop", 1, "superseded", "1.0.0"),
+		helmSecret(t, "shop", 2, "deployed", "1.1.0"),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: testNamespace}},
+	)
+	client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: "v1.21.0"}
+
+	k := NewDiscoveryFromClient(context.Background(), client, WithHelmReleases(true))
+
+	o, err := k.GenerateAll(testNamespace)
+	if err != nil {
+		t.Fatalf("GenerateAll() error = %v", err)
+	}
+
+	want := HelmRelease{
+		Name:         "shop",
+		Namespace:    testNamespace,
+		Revision:     2,
+		Status:       "deployed",
+		Chart:        "web",
+		ChartVersion: "1.1.0",
+		AppVersion:   "2.0",
+	}
+
+	if len(o.HelmReleases) != 1 || o.HelmReleases[0] != want {
+		t.Errorf("got releases %+v, want [%+v]", o.HelmReleases, want)
+	}
+}
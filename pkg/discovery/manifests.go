@@ -0,0 +1,174 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// LoadManifests reads the objects declared in YAML manifests, from files or directories, such as the output of helm
+// template or kustomize build. Objects without a namespace are put in the given namespace, and workloads are assumed
+// to have all their replicas available, as manifests have no status.
+func LoadManifests(namespace string, paths ...string) (*Objects, error) {
+	o := &Objects{}
+
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() || !isManifest(file) {
+				return nil
+			}
+
+			return o.loadManifest(namespace, file)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading manifests: %w", err)
+		}
+	}
+
+	if !o.HasNamespace(namespace) {
+		o.add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	}
+
+	o.ensureLists()
+
+	return o, nil
+}
+
+func isManifest(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+func (o *Objects) loadManifest(namespace, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", file, err)
+	}
+	defer f.Close()
+
+	r := utilyaml.NewYAMLReader(bufio.NewReader(f))
+
+	for {
+		doc, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(doc, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			log.Warn().Msgf("Skipping object of unknown kind in %s: %s", file, err)
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", file, err)
+		}
+
+		obj, err = manifestObject(obj)
+		if err != nil {
+			return fmt.Errorf("converting %s: %w", file, err)
+		}
+
+		if m, ok := obj.(metav1.Object); ok && m.GetNamespace() == "" && namespaced(obj) {
+			m.SetNamespace(namespace)
+		}
+
+		if !o.add(obj) {
+			log.Debug().Msgf("Skipping %s %s in %s", gvk.Kind, obj.(metav1.Object).GetName(), file)
+		}
+	}
+}
+
+// manifestObject converts the objects of older API versions, and sets the status of the workloads as if they were
+// rolled out.
+func manifestObject(obj runtime.Object) (runtime.Object, error) {
+	switch v := obj.(type) {
+	case *networkingv1beta1.Ingress:
+		ing, err := toNetworkingV1(*v)
+		if err != nil {
+			return nil, err
+		}
+
+		addServiceFromV1Beta1(ing, *v)
+
+		return ing, nil
+	case *appsv1.Deployment:
+		desired := replicas(v.Spec.Replicas)
+		v.Status.Replicas, v.Status.ReadyReplicas, v.Status.AvailableReplicas = desired, desired, desired
+	case *appsv1.StatefulSet:
+		desired := replicas(v.Spec.Replicas)
+		v.Status.Replicas, v.Status.ReadyReplicas = desired, desired
+	case *appsv1.ReplicaSet:
+		desired := replicas(v.Spec.Replicas)
+		v.Status.Replicas, v.Status.ReadyReplicas, v.Status.AvailableReplicas = desired, desired, desired
+	case *appsv1.DaemonSet:
+		// The number of nodes is unknown, draw daemonSets as running on one node.
+		v.Status.DesiredNumberScheduled, v.Status.NumberReady, v.Status.NumberAvailable = 1, 1, 1
+	}
+
+	return obj, nil
+}
+
+func replicas(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+
+	return *r
+}
+
+func namespaced(obj runtime.Object) bool {
+	switch obj.(type) {
+	case *corev1.Namespace, *rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding:
+		return false
+	default:
+		return true
+	}
+}
+
+// HasNamespace tells if the namespaces of the objects include a namespace.
+func (o *Objects) HasNamespace(namespace string) bool {
+	if o.Namespaces == nil {
+		return false
+	}
+
+	for _, ns := range o.Namespaces.Items {
+		if ns.Name == namespace {
+			return true
+		}
+	}
+
+	return false
+}
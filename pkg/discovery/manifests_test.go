@@ -0,0 +1,68 @@
+package discovery
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLoadManifests(t *testing.T) {
+	o, err := LoadManifests(testNamespace, "testdata")
+	if err != nil {
+		t.Fatalf("LoadManifests() error = %v", err)
+	}
+
+	if len(o.Namespaces.Items) != 1 || o.Namespaces.Items[0].Name != testNamespace {
+		t.Errorf("got namespaces %v, want %s only", o.Namespaces.Items, testNamespace)
+	}
+
+	if len(o.Deployments.Items) != 1 {
+		t.Fatalf("got %d deployments, want 1", len(o.Deployments.Items))
+	}
+
+	deploy := o.Deployments.Items[0]
+	if deploy.Namespace != testNamespace {
+		t.Errorf("got deployment namespace %q, want %q", deploy.Namespace, testNamespace)
+	}
+
+	if deploy.Status.AvailableReplicas != 2 {
+		t.Errorf("got %d available replicas, want the 2 desired ones", deploy.Status.AvailableReplicas)
+	}
+
+	if len(o.Services.Items) != 1 {
+		t.Errorf("got %d services, want 1", len(o.Services.Items))
+	}
+
+	if len(o.Ingresses.Items) != 1 || o.Ingresses.Items[0].Spec.Rules[0].HTTP.Paths[0].Backend.Service == nil {
+		t.Errorf("got ingresses %v, want the v1beta1 ingress converted to v1", o.Ingresses.Items)
+	}
+
+	if len(o.Pods.Items) != 0 {
+		t.Errorf("got %d pods, want none", len(o.Pods.Items))
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	o, err := LoadManifests(testNamespace, "testdata")
+	if err != nil {
+		t.Fatalf("LoadManifests() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	if err = SaveSnapshot(&buf, o); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	loaded, err := LoadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+
+	if len(loaded.Deployments.Items) != 1 || loaded.Deployments.Items[0].Name != "web" {
+		t.Errorf("got deployments %v, want web", loaded.Deployments.Items)
+	}
+
+	if loaded.EndpointSlices != nil {
+		t.Error("got EndpointSlices, want them left undiscovered")
+	}
+}
@@ -0,0 +1,347 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// StableName returns the name of an object with the hashes its controllers generate replaced with a *, so that it
+// doesn't change across rollouts: web-5d9f7c for a replicaSet and web-5d9f7c-x2x8j for its pods both become web-*.
+func StableName(o metav1.Object) string {
+	if hash := o.GetLabels()[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" {
+		if i := strings.Index(o.GetName()+"-", "-"+hash+"-"); i >= 0 {
+			return o.GetName()[:i] + "-*"
+		}
+	}
+
+	if o.GetGenerateName() != "" {
+		return o.GetGenerateName() + "*"
+	}
+
+	return o.GetName()
+}
+
+// add appends an object to the list of its type, and tells if its type is supported.
+func (o *Objects) add(obj runtime.Object) bool {
+	switch v := obj.(type) {
+	case *corev1.Namespace:
+		if o.Namespaces == nil {
+			o.Namespaces = &corev1.NamespaceList{}
+		}
+
+		o.Namespaces.Items = append(o.Namespaces.Items, *v)
+	case *corev1.Endpoints:
+		if o.Endpoints == nil {
+			o.Endpoints = &corev1.EndpointsList{}
+		}
+
+		o.Endpoints.Items = append(o.Endpoints.Items, *v)
+	case *discoveryv1.EndpointSlice:
+		if o.EndpointSlices == nil {
+			o.EndpointSlices = &discoveryv1.EndpointSliceList{}
+		}
+
+		o.EndpointSlices.Items = append(o.EndpointSlices.Items, *v)
+	case *corev1.Pod:
+		if o.Pods == nil {
+			o.Pods = &corev1.PodList{}
+		}
+
+		o.Pods.Items = append(o.Pods.Items, *v)
+	case *corev1.PersistentVolumeClaim:
+		if o.PersistentVolumeClaims == nil {
+			o.PersistentVolumeClaims = &corev1.PersistentVolumeClaimList{}
+		}
+
+		o.PersistentVolumeClaims.Items = append(o.PersistentVolumeClaims.Items, *v)
+	case *corev1.Service:
+		if o.Services == nil {
+			o.Services = &corev1.ServiceList{}
+		}
+
+		o.Services.Items = append(o.Services.Items, *v)
+	case *appsv1.DaemonSet:
+		if o.DaemonSets == nil {
+			o.DaemonSets = &appsv1.DaemonSetList{}
+		}
+
+		o.DaemonSets.Items = append(o.DaemonSets.Items, *v)
+	case *appsv1.Deployment:
+		if o.Deployments == nil {
+			o.Deployments = &appsv1.DeploymentList{}
+		}
+
+		o.Deployments.Items = append(o.Deployments.Items, *v)
+	case *appsv1.ReplicaSet:
+		if o.ReplicaSets == nil {
+			o.ReplicaSets = &appsv1.ReplicaSetList{}
+		}
+
+		o.ReplicaSets.Items = append(o.ReplicaSets.Items, *v)
+	case *appsv1.StatefulSet:
+		if o.StatefulSets == nil {
+			o.StatefulSets = &appsv1.StatefulSetList{}
+		}
+
+		o.StatefulSets.Items = append(o.StatefulSets.Items, *v)
+	case *networkingv1.Ingress:
+		if o.Ingresses == nil {
+			o.Ingresses = &networkingv1.IngressList{}
+		}
+
+		o.Ingresses.Items = append(o.Ingresses.Items, *v)
+	case *corev1.ServiceAccount:
+		if o.ServiceAccounts == nil {
+			o.ServiceAccounts = &corev1.ServiceAccountList{}
+		}
+
+		o.ServiceAccounts.Items = append(o.ServiceAccounts.Items, *v)
+	case *rbacv1.Role:
+		if o.Roles == nil {
+			o.Roles = &rbacv1.RoleList{}
+		}
+
+		o.Roles.Items = append(o.Roles.Items, *v)
+	case *rbacv1.RoleBinding:
+		if o.RoleBindings == nil {
+			o.RoleBindings = &rbacv1.RoleBindingList{}
+		}
+
+		o.RoleBindings.Items = append(o.RoleBindings.Items, *v)
+	case *rbacv1.ClusterRole:
+		if o.ClusterRoles == nil {
+			o.ClusterRoles = &rbacv1.ClusterRoleList{}
+		}
+
+		o.ClusterRoles.Items = append(o.ClusterRoles.Items, *v)
+	case *rbacv1.ClusterRoleBinding:
+		if o.ClusterRoleBindings == nil {
+			o.ClusterRoleBindings = &rbacv1.ClusterRoleBindingList{}
+		}
+
+		o.ClusterRoleBindings.Items = append(o.ClusterRoleBindings.Items, *v)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// items lists the objects of every supported type.
+func (o *Objects) items() []runtime.Object {
+	var items []runtime.Object
+
+	if o.Namespaces != nil {
+		for i := range o.Namespaces.Items {
+			items = append(items, &o.Namespaces.Items[i])
+		}
+	}
+
+	if o.Endpoints != nil {
+		for i := range o.Endpoints.Items {
+			items = append(items, &o.Endpoints.Items[i])
+		}
+	}
+
+	if o.EndpointSlices != nil {
+		for i := range o.EndpointSlices.Items {
+			items = append(items, &o.EndpointSlices.Items[i])
+		}
+	}
+
+	if o.Pods != nil {
+		for i := range o.Pods.Items {
+			items = append(items, &o.Pods.Items[i])
+		}
+	}
+
+	if o.PersistentVolumeClaims != nil {
+		for i := range o.PersistentVolumeClaims.Items {
+			items = append(items, &o.PersistentVolumeClaims.Items[i])
+		}
+	}
+
+	if o.Services != nil {
+		for i := range o.Services.Items {
+			items = append(items, &o.Services.Items[i])
+		}
+	}
+
+	if o.DaemonSets != nil {
+		for i := range o.DaemonSets.Items {
+			items = append(items, &o.DaemonSets.Items[i])
+		}
+	}
+
+	if o.Deployments != nil {
+		for i := range o.Deployments.Items {
+			items = append(items, &o.Deployments.Items[i])
+		}
+	}
+
+	if o.ReplicaSets != nil {
+		for i := range o.ReplicaSets.Items {
+			items = append(items, &o.ReplicaSets.Items[i])
+		}
+	}
+
+	if o.StatefulSets != nil {
+		for i := range o.StatefulSets.Items {
+			items = append(items, &o.StatefulSets.Items[i])
+		}
+	}
+
+	if o.Ingresses != nil {
+		for i := range o.Ingresses.Items {
+			items = append(items, &o.Ingresses.Items[i])
+		}
+	}
+
+	if o.ServiceAccounts != nil {
+		for i := range o.ServiceAccounts.Items {
+			items = append(items, &o.ServiceAccounts.Items[i])
+		}
+	}
+
+	if o.Roles != nil {
+		for i := range o.Roles.Items {
+			items = append(items, &o.Roles.Items[i])
+		}
+	}
+
+	if o.RoleBindings != nil {
+		for i := range o.RoleBindings.Items {
+			items = append(items, &o.RoleBindings.Items[i])
+		}
+	}
+
+	if o.ClusterRoles != nil {
+		for i := range o.ClusterRoles.Items {
+			items = append(items, &o.ClusterRoles.Items[i])
+		}
+	}
+
+	if o.ClusterRoleBindings != nil {
+		for i := range o.ClusterRoleBindings.Items {
+			items = append(items, &o.ClusterRoleBindings.Items[i])
+		}
+	}
+
+	return items
+}
+
+// ensureLists creates the empty lists of the objects that are always discovered, for the objects that were not
+// read from a cluster.
+func (o *Objects) ensureLists() {
+	if o.Namespaces == nil {
+		o.Namespaces = &corev1.NamespaceList{}
+	}
+
+	if o.Endpoints == nil {
+		o.Endpoints = &corev1.EndpointsList{}
+	}
+
+	if o.Pods == nil {
+		o.Pods = &corev1.PodList{}
+	}
+
+	if o.PersistentVolumeClaims == nil {
+		o.PersistentVolumeClaims = &corev1.PersistentVolumeClaimList{}
+	}
+
+	if o.Services == nil {
+		o.Services = &corev1.ServiceList{}
+	}
+
+	if o.DaemonSets == nil {
+		o.DaemonSets = &appsv1.DaemonSetList{}
+	}
+
+	if o.Deployments == nil {
+		o.Deployments = &appsv1.DeploymentList{}
+	}
+
+	if o.ReplicaSets == nil {
+		o.ReplicaSets = &appsv1.ReplicaSetList{}
+	}
+
+	if o.StatefulSets == nil {
+		o.StatefulSets = &appsv1.StatefulSetList{}
+	}
+
+	if o.Ingresses == nil {
+		o.Ingresses = &networkingv1.IngressList{}
+	}
+
+	if o.ServiceAccounts != nil {
+		if o.Roles == nil {
+			o.Roles = &rbacv1.RoleList{}
+		}
+
+		if o.RoleBindings == nil {
+			o.RoleBindings = &rbacv1.RoleBindingList{}
+		}
+
+		if o.ClusterRoles == nil {
+			o.ClusterRoles = &rbacv1.ClusterRoleList{}
+		}
+
+		if o.ClusterRoleBindings == nil {
+			o.ClusterRoleBindings = &rbacv1.ClusterRoleBindingList{}
+		}
+	}
+}
+
+func objectKey(obj runtime.Object) (string, error) {
+	m, ok := obj.(metav1.Object)
+	if !ok {
+		return "", fmt.Errorf("%T has no object metadata", obj)
+	}
+
+	return fmt.Sprintf("%T/%s/%s", obj, m.GetNamespace(), StableName(m)), nil
+}
+
+// Merge returns the objects of newer, along with the objects of older that newer doesn't have anymore. Objects are
+// matched by their type, namespace and stable name.
+func Merge(newer, older *Objects) (*Objects, error) {
+	merged := &Objects{
+		Version:            newer.Version,
+		ReferencedServices: newer.ReferencedServices,
+		HelmReleases:       newer.HelmReleases,
+	}
+	keys := make(map[string]bool)
+
+	for _, obj := range newer.items() {
+		key, err := objectKey(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		keys[key] = true
+
+		merged.add(obj)
+	}
+
+	for _, obj := range older.items() {
+		key, err := objectKey(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if !keys[key] {
+			merged.add(obj)
+		}
+	}
+
+	merged.ensureLists()
+
+	return merged, nil
+}
@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStableName(t *testing.T) {
+	hash := map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d9f7c"}
+
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want string
+	}{
+		{
+			name: "replicaSet",
+			meta: metav1.ObjectMeta{Name: "web-5d9f7c", Labels: hash},
+			want: "web-*",
+		},
+		{
+			name: "pod of a replicaSet",
+			meta: metav1.ObjectMeta{Name: "web-5d9f7c-x2x8j", GenerateName: "web-5d9f7c-", Labels: hash},
+			want: "web-*",
+		},
+		{
+			name: "pod of a daemonSet",
+			meta: metav1.ObjectMeta{Name: "agent-x2x8j", GenerateName: "agent-"},
+			want: "agent-*",
+		},
+		{
+			name: "pod of a statefulSet",
+			meta: metav1.ObjectMeta{Name: "db-0"},
+			want: "db-0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StableName(&tt.meta); got != tt.want {
+				t.Errorf("StableName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	pod := func(name, hash string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:         name,
+			GenerateName: "web-" + hash + "-",
+			Labels:       map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+		}}
+	}
+
+	service := func(name string) corev1.Service {
+		return corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	older := &Objects{
+		Pods:     &corev1.PodList{Items: []corev1.Pod{pod("web-aaaaa-11111", "aaaaa")}},
+		Services: &corev1.ServiceList{Items: []corev1.Service{service("web"), service("legacy")}},
+	}
+	newer := &Objects{
+		Pods:     &corev1.PodList{Items: []corev1.Pod{pod("web-bbbbb-22222", "bbbbb")}},
+		Services: &corev1.ServiceList{Items: []corev1.Service{service("web")}},
+	}
+
+	merged, err := Merge(newer, older)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	if len(merged.Pods.Items) != 1 || merged.Pods.Items[0].Name != "web-bbbbb-22222" {
+		t.Errorf("got pods %v, want the newer pod only", merged.Pods.Items)
+	}
+
+	if len(merged.Services.Items) != 2 {
+		t.Errorf("got %d services, want web and the removed legacy service", len(merged.Services.Items))
+	}
+}
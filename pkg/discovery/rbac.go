@@ -0,0 +1,124 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	kindClusterRole = "ClusterRole"
+	// serviceAccountUserPrefix starts the user names of the service accounts.
+	serviceAccountUserPrefix = "system:serviceaccount"
+	// serviceAccountsGroup is the group of all the service accounts, followed by :<namespace> for the ones of a
+	// namespace.
+	serviceAccountsGroup = "system:serviceaccounts"
+)
+
+// generateRBAC gets the service accounts, roles and role bindings of a namespace, and the cluster role bindings
+// granting permissions to its service accounts along with the cluster roles they reference.
+func (k *Discovery) generateRBAC(namespace string) error {
+	sa, err := k.client.CoreV1().ServiceAccounts(namespace).List(k.ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("getting service accounts: %w", err)
+	}
+
+	k.objects.ServiceAccounts = sa
+
+	roles, err := k.client.RbacV1().Roles(namespace).List(k.ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("getting roles: %w", err)
+	}
+
+	k.objects.Roles = roles
+
+	rb, err := k.client.RbacV1().RoleBindings(namespace).List(k.ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("getting role bindings: %w", err)
+	}
+
+	k.objects.RoleBindings = rb
+
+	crb, err := k.client.RbacV1().ClusterRoleBindings().List(k.ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("getting cluster role bindings: %w", err)
+	}
+
+	k.objects.ClusterRoleBindings = &rbacv1.ClusterRoleBindingList{}
+
+	for _, b := range crb.Items {
+		if bindsServiceAccountsOf(b.Subjects, namespace) {
+			k.objects.ClusterRoleBindings.Items = append(k.objects.ClusterRoleBindings.Items, b)
+		}
+	}
+
+	cr, err := k.client.RbacV1().ClusterRoles().List(k.ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("getting cluster roles: %w", err)
+	}
+
+	k.objects.ClusterRoles = &rbacv1.ClusterRoleList{}
+
+	for _, r := range cr.Items {
+		if k.clusterRoleReferenced(r.Name) {
+			k.objects.ClusterRoles.Items = append(k.objects.ClusterRoles.Items, r)
+		}
+	}
+
+	return nil
+}
+
+func (k *Discovery) clusterRoleReferenced(name string) bool {
+	for _, b := range k.objects.RoleBindings.Items {
+		if b.RoleRef.Kind == kindClusterRole && b.RoleRef.Name == name {
+			return true
+		}
+	}
+
+	for _, b := range k.objects.ClusterRoleBindings.Items {
+		if b.RoleRef.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func bindsServiceAccountsOf(subjects []rbacv1.Subject, namespace string) bool {
+	for _, s := range subjects {
+		if _, ok := ServiceAccountSubject(s, "", namespace); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ServiceAccountSubject tells if a subject of a binding grants its permissions to service accounts of a namespace,
+// and returns the name of the service account: a service account, written without namespace in the bindings of its
+// namespace, or the system:serviceaccount:<namespace>:<name> user. The system:serviceaccounts and
+// system:serviceaccounts:<namespace> groups grant them to every service account, for which the name is empty.
+func ServiceAccountSubject(s rbacv1.Subject, bindingNamespace, namespace string) (name string, ok bool) {
+	switch s.Kind {
+	case rbacv1.ServiceAccountKind:
+		ns := s.Namespace
+		if ns == "" {
+			ns = bindingNamespace
+		}
+
+		if ns == namespace {
+			return s.Name, true
+		}
+	case rbacv1.UserKind:
+		parts := strings.Split(s.Name, ":")
+		if len(parts) == 4 && parts[0]+":"+parts[1] == serviceAccountUserPrefix && parts[2] == namespace {
+			return parts[3], true
+		}
+	case rbacv1.GroupKind:
+		return "", s.Name == serviceAccountsGroup || s.Name == serviceAccountsGroup+":"+namespace
+	}
+
+	return "", false
+}
@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestServiceAccountSubject(t *testing.T) {
+	tests := []struct {
+		name             string
+		subject          rbacv1.Subject
+		bindingNamespace string
+		want             string
+		ok               bool
+	}{
+		{
+			name:             "service account of the binding namespace",
+			subject:          rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "web"},
+			bindingNamespace: testNamespace,
+			want:             "web",
+			ok:               true,
+		},
+		{
+			name:    "service account",
+			subject: rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "web", Namespace: testNamespace},
+			want:    "web",
+			ok:      true,
+		},
+		{
+			name:    "service account of another namespace",
+			subject: rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "web", Namespace: "other"},
+		},
+		{
+			name:    "service account user",
+			subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "system:serviceaccount:app:web"},
+			want:    "web",
+			ok:      true,
+		},
+		{
+			name:    "user",
+			subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "jane@example.com"},
+		},
+		{
+			name:    "service accounts of the namespace",
+			subject: rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:app"},
+			ok:      true,
+		},
+		{
+			name:    "all service accounts",
+			subject: rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts"},
+			ok:      true,
+		},
+		{
+			name:    "service accounts of another namespace",
+			subject: rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:other"},
+		},
+		{
+			name:    "authenticated users",
+			subject: rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:authenticated"},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := ServiceAccountSubject(test.subject, test.bindingNamespace, testNamespace)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: ServiceAccountSubject() = %q, %t, want %q, %t", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGenerateAllRBAC(t *testing.T) {
+	binding := func(name string, subject rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Subjects:   []rbacv1.Subject{subject},
+			RoleRef:    rbacv1.RoleRef{Kind: kindClusterRole, Name: name},
+		}
+	}
+
+	role := func(name string) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace}},
+		binding("web", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "web", Namespace: testNamespace}),
+		binding("group", rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:app"}),
+		binding("other", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "web", Namespace: "other"}),
+		binding("users", rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:authenticated"}),
+		role("web"), role("group"), role("other"), role("users"),
+	)
+	client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &k8sversion.Info{GitVersion: "v1.21.0"}
+
+	k := NewDiscoveryFromClient(context.Background(), client, WithRBAC(true))
+
+	o, err := k.GenerateAll(testNamespace)
+	if err != nil {
+		t.Fatalf("GenerateAll() error = %v", err)
+	}
+
+	var bindings, roles []string
+
+	for _, b := range o.ClusterRoleBindings.Items {
+		bindings = append(bindings, b.Name)
+	}
+
+	for _, r := range o.ClusterRoles.Items {
+		roles = append(roles, r.Name)
+	}
+
+	sort.Strings(bindings)
+	sort.Strings(roles)
+
+	if got := strings.Join(bindings, ","); got != "group,web" {
+		t.Errorf("got cluster role bindings %s, want group,web", got)
+	}
+
+	if got := strings.Join(roles, ","); got != "group,web" {
+		t.Errorf("got cluster roles %s, want group,web", got)
+	}
+}
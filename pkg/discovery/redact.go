@@ -0,0 +1,566 @@
+package discovery
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedactMode is how a Redactor derives the aliases of the names.
+type RedactMode string
+
+const (
+	// RedactHashed aliases the names with a hash of their value, as in app-3fa2b1c9, stable across runs and sources.
+	RedactHashed RedactMode = "hash"
+	// RedactSequential numbers the aliases in the order of the names, as in app-1, app-2.
+	RedactSequential RedactMode = "sequential"
+)
+
+// RedactModes returns the ways a Redactor can derive aliases.
+func RedactModes() []RedactMode {
+	return []RedactMode{RedactHashed, RedactSequential}
+}
+
+// The kinds of aliases, which prefix them.
+const (
+	aliasNamespace = "ns"
+	aliasName      = "app"
+	aliasHost      = "host"
+	aliasIP        = "ip"
+)
+
+// defaultServiceAccount is the service account of the pods not naming one.
+const defaultServiceAccount = "default"
+
+// IngressClassAnnotation is the deprecated annotation setting the class of an ingress.
+const IngressClassAnnotation = "kubernetes.io/ingress.class"
+
+// aliasHostDomain ends the aliases of the hosts, a domain reserved for examples.
+const aliasHostDomain = ".example"
+
+// Redactor replaces the names of the namespaces, workloads, pods, services, ingresses, service accounts and Helm
+// releases, the hosts and the IP addresses of objects with aliases, for their diagrams to be shared without leaking
+// them. A value keeps the same alias across all the objects a Redactor redacts. The names of the pods and replicaSets
+// starting with the name of their owner keep the alias of their owner as prefix.
+type Redactor struct {
+	mode          RedactMode
+	salt          string
+	clusterDomain string
+	aliases       map[string]string
+	values        map[string]string
+	counts        map[string]int
+}
+
+// NewRedactor creates a Redactor. The salt is hashed with the names by RedactHashed, for their aliases not to be
+// guessed by hashing likely names. The cluster domain tells the ExternalName services pointing to in-cluster services,
+// as ServiceReference does.
+func NewRedactor(mode RedactMode, salt, clusterDomain string) (*Redactor, error) {
+	if mode != RedactHashed && mode != RedactSequential {
+		return nil, fmt.Errorf("unknown redaction mode %q, want %s or %s", mode, RedactHashed, RedactSequential)
+	}
+
+	return &Redactor{
+		mode:          mode,
+		salt:          salt,
+		clusterDomain: clusterDomain,
+		aliases:       make(map[string]string),
+		values:        make(map[string]string),
+		counts:        make(map[string]int),
+	}, nil
+}
+
+// Alias returns the alias of a value, or the value itself when it isn't redacted.
+func (r *Redactor) Alias(value string) string {
+	if alias, ok := r.aliases[value]; ok {
+		return alias
+	}
+
+	return value
+}
+
+// Aliases returns the values redacted so far, by alias, to be kept private.
+func (r *Redactor) Aliases() map[string]string {
+	values := make(map[string]string, len(r.values))
+	for alias, value := range r.values {
+		values[alias] = value
+	}
+
+	return values
+}
+
+// Redact returns a copy of the objects, with every string equal to a redacted value replaced with its alias: in the
+// names, namespaces and references of the objects, in the label values and selectors naming them, and in the hosts
+// and addresses of the ingresses, services and endpoints.
+func (r *Redactor) Redact(o *Objects) (*Objects, error) {
+	lists, err := decodeLists(o)
+	if err != nil {
+		return nil, err
+	}
+
+	r.collect(o, lists)
+
+	redacted := &Objects{Version: o.Version}
+	dst := reflect.ValueOf(redacted).Elem()
+
+	for i, list := range lists {
+		if list == nil {
+			continue
+		}
+
+		content, err := json.Marshal(r.replace(list))
+		if err != nil {
+			return nil, fmt.Errorf("redacting objects: %w", err)
+		}
+
+		field := dst.FieldByName(listFields[i])
+		if err := json.Unmarshal(content, field.Addr().Interface()); err != nil {
+			return nil, fmt.Errorf("redacting objects: %w", err)
+		}
+	}
+
+	return redacted, nil
+}
+
+// listFields are the fields of Objects holding objects to redact.
+var listFields = func() []string {
+	var fields []string
+
+	t := reflect.TypeOf(Objects{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := t.Field(i).Name; name != "Version" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}()
+
+// decodeLists returns the lists of objects as generic JSON values, nil for the lists not discovered.
+func decodeLists(o *Objects) ([]interface{}, error) {
+	src := reflect.ValueOf(o).Elem()
+	lists := make([]interface{}, len(listFields))
+
+	for i, name := range listFields {
+		field := src.FieldByName(name)
+		if field.IsNil() {
+			continue
+		}
+
+		content, err := json.Marshal(field.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("redacting objects: %w", err)
+		}
+
+		// Numbers are kept as they are written, not to lose the precision of the large ones.
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&lists[i]); err != nil {
+			return nil, fmt.Errorf("redacting objects: %w", err)
+		}
+	}
+
+	return lists, nil
+}
+
+// collect gives aliases to the values of the objects to redact, in a stable order: namespaces, names, hosts, then IP
+// addresses, each sorted.
+func (r *Redactor) collect(o *Objects, lists []interface{}) {
+	var (
+		namespaces, names, hosts, ips []string
+		serviceReferences             []string
+	)
+
+	owners := make(map[string]string)
+
+	for _, obj := range o.items() {
+		m, ok := obj.(metav1.Object)
+		if !ok {
+			continue
+		}
+
+		kind := Kind(obj)
+
+		switch kind {
+		case "Namespace":
+			namespaces = append(namespaces, m.GetName())
+		case "ReplicaSet", "Pod":
+			owner := ownerPrefix(m)
+			if owner == "" {
+				names = append(names, m.GetName())
+
+				break
+			}
+
+			names = append(names, owner)
+			owners[m.GetName()] = owner
+
+			if strings.HasPrefix(m.GetGenerateName(), owner) {
+				owners[m.GetGenerateName()] = owner
+			}
+		case "Deployment", "DaemonSet", "StatefulSet", "Service", "Ingress", "ServiceAccount", "RoleBinding",
+			"ClusterRoleBinding", "Role", "ClusterRole":
+			names = append(names, m.GetName())
+		}
+
+		if m.GetNamespace() != "" {
+			namespaces = append(namespaces, m.GetNamespace())
+		}
+	}
+
+	for _, release := range o.HelmReleases {
+		names = append(names, release.Name, release.Chart)
+		namespaces = append(namespaces, release.Namespace)
+	}
+
+	subjectNames, subjectNamespaces, subjects := subjectValues(o)
+	names = append(append(names, roleReferences(o)...), subjectNames...)
+	namespaces = append(namespaces, subjectNamespaces...)
+
+	for _, svc := range services(o.Services, o.ReferencedServices) {
+		if name, ns, ok := ServiceReference(svc.Spec.ExternalName, r.clusterDomain); ok {
+			names, namespaces = append(names, name), append(namespaces, ns)
+			serviceReferences = append(serviceReferences, svc.Spec.ExternalName)
+		} else if svc.Spec.ExternalName != "" {
+			hosts = append(hosts, svc.Spec.ExternalName)
+		}
+
+		for _, lb := range svc.Status.LoadBalancer.Ingress {
+			hosts = append(hosts, lb.Hostname)
+		}
+	}
+
+	if o.Ingresses != nil {
+		for _, ing := range o.Ingresses.Items {
+			ingressNames, ingressHosts := ingressValues(ing)
+			names, hosts = append(names, ingressNames...), append(hosts, ingressHosts...)
+		}
+	}
+
+	var images []string
+
+	for _, list := range lists {
+		walkStrings(list, func(s string) {
+			if net.ParseIP(s) != nil {
+				ips = append(ips, s)
+			}
+		})
+
+		walkImages(list, func(image string) {
+			if registry, _, ok := imageRegistry(image); ok {
+				hosts = append(hosts, registry)
+				images = append(images, image)
+			}
+		})
+	}
+
+	// The names of owners owned themselves, such as the replicaSets of deployments, are derived from their owner. The
+	// default service account keeps its name, as the pods not naming their service account use it.
+	roots := names[:0]
+
+	for _, name := range names {
+		if _, ok := owners[name]; !ok && name != defaultServiceAccount {
+			roots = append(roots, name)
+		}
+	}
+
+	r.assign(aliasNamespace, namespaces)
+	r.assign(aliasName, roots)
+
+	derived := make([]string, 0, len(owners))
+	for name := range owners {
+		derived = append(derived, name)
+	}
+
+	sort.Strings(derived)
+
+	for _, name := range derived {
+		r.derive(name, owners)
+	}
+
+	for _, ref := range serviceReferences {
+		name, ns, _ := ServiceReference(ref, r.clusterDomain)
+		r.set(ref, r.Alias(name)+"."+r.Alias(ns)+strings.TrimPrefix(ref, name+"."+ns))
+	}
+
+	// The user names and groups of the service accounts are made of the names of their namespace and service account.
+	for _, subject := range subjects {
+		parts := strings.Split(subject, ":")
+		for i := 2; i < len(parts); i++ {
+			parts[i] = r.Alias(parts[i])
+		}
+
+		r.set(subject, strings.Join(parts, ":"))
+	}
+
+	r.assign(aliasHost, hosts)
+	r.assign(aliasIP, ips)
+
+	// The images keep their repository and tag, pulled from the alias of their registry.
+	for _, image := range images {
+		registry, path, _ := imageRegistry(image)
+		r.set(image, r.Alias(registry)+path)
+	}
+}
+
+// roleReferences returns the names of the roles the bindings reference, discovered or not.
+func roleReferences(o *Objects) []string {
+	var names []string
+
+	if o.RoleBindings != nil {
+		for _, b := range o.RoleBindings.Items {
+			names = append(names, b.RoleRef.Name)
+		}
+	}
+
+	if o.ClusterRoleBindings != nil {
+		for _, b := range o.ClusterRoleBindings.Items {
+			names = append(names, b.RoleRef.Name)
+		}
+	}
+
+	return names
+}
+
+// bindingSubjects returns the subjects of the role and cluster role bindings.
+func bindingSubjects(o *Objects) []rbacv1.Subject {
+	var subjects []rbacv1.Subject
+
+	if o.RoleBindings != nil {
+		for _, b := range o.RoleBindings.Items {
+			subjects = append(subjects, b.Subjects...)
+		}
+	}
+
+	if o.ClusterRoleBindings != nil {
+		for _, b := range o.ClusterRoleBindings.Items {
+			subjects = append(subjects, b.Subjects...)
+		}
+	}
+
+	return subjects
+}
+
+// imageRegistry splits the registry host of an image from the rest of its reference, when it names one: a first
+// component with a dot or a port, or localhost, as the container runtimes tell them.
+func imageRegistry(image string) (registry, path string, ok bool) {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return "", "", false
+	}
+
+	registry = image[:i]
+	if !strings.ContainsAny(registry, ".:") && registry != "localhost" {
+		return "", "", false
+	}
+
+	return registry, image[i:], true
+}
+
+// ingressValues returns the names an ingress references, its class, TLS secrets and resource backends, and its hosts.
+func ingressValues(ing networkingv1.Ingress) (names, hosts []string) {
+	for _, rule := range ing.Spec.Rules {
+		hosts = append(hosts, rule.Host)
+	}
+
+	for _, tls := range ing.Spec.TLS {
+		hosts = append(hosts, tls.Hosts...)
+		names = append(names, tls.SecretName)
+	}
+
+	if ing.Spec.IngressClassName != nil {
+		names = append(names, *ing.Spec.IngressClassName)
+	}
+
+	names = append(names, ing.Annotations[IngressClassAnnotation])
+
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		hosts = append(hosts, lb.Hostname)
+	}
+
+	return append(names, ingressResources(ing)...), hosts
+}
+
+// subjectValues returns the names and namespaces of the service accounts the bindings bind, and the user names and
+// groups naming them, which are made of these names.
+func subjectValues(o *Objects) (names, namespaces, subjects []string) {
+	for _, subject := range bindingSubjects(o) {
+		parts := strings.Split(subject.Name, ":")
+		user := len(parts) == 4 && parts[0]+":"+parts[1] == serviceAccountUserPrefix
+
+		switch {
+		case subject.Kind == rbacv1.ServiceAccountKind:
+			names, namespaces = append(names, subject.Name), append(namespaces, subject.Namespace)
+		case subject.Kind == rbacv1.UserKind && user:
+			names, namespaces = append(names, parts[3]), append(namespaces, parts[2])
+			subjects = append(subjects, subject.Name)
+		case subject.Kind == rbacv1.GroupKind && strings.HasPrefix(subject.Name, serviceAccountsGroup+":"):
+			namespaces = append(namespaces, strings.TrimPrefix(subject.Name, serviceAccountsGroup+":"))
+			subjects = append(subjects, subject.Name)
+		}
+	}
+
+	return names, namespaces, subjects
+}
+
+// ingressResources returns the names of the resources an ingress routes to, instead of services.
+func ingressResources(ing networkingv1.Ingress) []string {
+	var names []string
+
+	if b := ing.Spec.DefaultBackend; b != nil && b.Resource != nil {
+		names = append(names, b.Resource.Name)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Resource != nil {
+				names = append(names, path.Backend.Resource.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+// ownerPrefix returns the name of the controller of an object when it starts the name of the object, as the
+// controllers of replicaSets and pods name them.
+func ownerPrefix(m metav1.Object) string {
+	for _, ref := range m.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller && strings.HasPrefix(m.GetName(), ref.Name+"-") {
+			return ref.Name
+		}
+	}
+
+	return ""
+}
+
+// derive gives a name starting with the name of its owner the alias of its owner as prefix.
+func (r *Redactor) derive(name string, owners map[string]string) string {
+	if alias, ok := r.aliases[name]; ok {
+		return alias
+	}
+
+	owner := owners[name]
+
+	prefix := r.Alias(owner)
+	if _, ok := owners[owner]; ok {
+		prefix = r.derive(owner, owners)
+	}
+
+	r.set(name, prefix+strings.TrimPrefix(name, owner))
+
+	return r.aliases[name]
+}
+
+// assign gives aliases of a kind to the values without one, in their order.
+func (r *Redactor) assign(kind string, values []string) {
+	sort.Strings(values)
+
+	for i, v := range values {
+		if _, ok := r.aliases[v]; ok || v == "" || (i > 0 && values[i-1] == v) {
+			continue
+		}
+
+		var id string
+
+		if r.mode == RedactHashed {
+			sum := sha256.Sum256([]byte(r.salt + "\x00" + v))
+			id = fmt.Sprintf("%x", sum[:4])
+		} else {
+			r.counts[kind]++
+			id = fmt.Sprint(r.counts[kind])
+		}
+
+		alias := kind + "-" + id
+		if kind == aliasHost {
+			alias += aliasHostDomain
+		}
+
+		r.set(v, alias)
+	}
+}
+
+func (r *Redactor) set(value, alias string) {
+	r.aliases[value] = alias
+	r.values[alias] = value
+}
+
+// replace replaces the strings of a generic JSON value having an alias. The API versions and kinds are kept.
+func (r *Redactor) replace(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if k != "apiVersion" && k != "kind" {
+				t[k] = r.replace(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = r.replace(e)
+		}
+	case string:
+		return r.Alias(t)
+	}
+
+	return v
+}
+
+func walkStrings(v interface{}, f func(string)) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, e := range t {
+			walkStrings(e, f)
+		}
+	case []interface{}:
+		for _, e := range t {
+			walkStrings(e, f)
+		}
+	case string:
+		f(t)
+	}
+}
+
+// walkImages calls a function with the images of the containers of a generic JSON value, in their specs and statuses.
+func walkImages(v interface{}, f func(string)) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if image, ok := e.(string); ok && k == "image" {
+				f(image)
+			} else {
+				walkImages(e, f)
+			}
+		}
+	case []interface{}:
+		for _, e := range t {
+			walkImages(e, f)
+		}
+	}
+}
+
+// services lists the services of service lists, some of which may not have been discovered.
+func services(lists ...*corev1.ServiceList) []corev1.Service {
+	var items []corev1.Service
+
+	for _, list := range lists {
+		if list != nil {
+			items = append(items, list.Items...)
+		}
+	}
+
+	return items
+}
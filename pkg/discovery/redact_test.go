@@ -0,0 +1,153 @@
+package discovery
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func redactFixture() *Objects {
+	controller := true
+	selector := map[string]string{"app": "web"}
+	owner := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+	}
+
+	return &Objects{
+		Namespaces: &corev1.NamespaceList{Items: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}}},
+		Deployments: &appsv1.DeploymentList{Items: []appsv1.Deployment{{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Labels: selector},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: selector}},
+		}}},
+		ReplicaSets: &appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-5d8f7",
+				Namespace:       testNamespace,
+				OwnerReferences: owner("Deployment", "web"),
+			},
+		}}},
+		Pods: &corev1.PodList{Items: []corev1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-5d8f7-x2x9q",
+				GenerateName:    "web-5d8f7-",
+				Namespace:       testNamespace,
+				Labels:          selector,
+				OwnerReferences: owner("ReplicaSet", "web-5d8f7"),
+			},
+			Status: corev1.PodStatus{PodIP: "10.1.2.3"},
+		}}},
+		Services: &corev1.ServiceList{Items: []corev1.Service{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+				Spec:       corev1.ServiceSpec{Selector: selector, ClusterIP: "10.0.0.10"},
+				Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb-1234.elb.amazonaws.com"}},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: testNamespace},
+				Spec: corev1.ServiceSpec{
+					Type:         corev1.ServiceTypeExternalName,
+					ExternalName: "postgres.data.svc.cluster.local",
+				},
+			},
+		}},
+		HelmReleases: []HelmRelease{{Name: "web", Namespace: testNamespace, Chart: "shop-chart", ChartVersion: "1.2.0"}},
+		Ingresses: &networkingv1.IngressList{Items: []networkingv1.Ingress{{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "shop.internal.corp"}}},
+		}}},
+	}
+}
+
+func TestRedact(t *testing.T) {
+	r, err := NewRedactor(RedactSequential, "", "")
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	o, err := r.Redact(redactFixture())
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+
+	web, ns := r.Alias("web"), r.Alias(testNamespace)
+	if web == "web" || ns == testNamespace {
+		t.Fatalf("got aliases %s and %s, want them redacted", web, ns)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "deployment", got: o.Deployments.Items[0].Name, want: web},
+		{name: "namespace", got: o.Deployments.Items[0].Namespace, want: ns},
+		{name: "selector", got: o.Services.Items[0].Spec.Selector["app"], want: web},
+		{name: "replicaSet", got: o.ReplicaSets.Items[0].Name, want: web + "-5d8f7"},
+		{name: "pod", got: o.Pods.Items[0].Name, want: web + "-5d8f7-x2x9q"},
+		{name: "pod owner", got: o.Pods.Items[0].OwnerReferences[0].Name, want: web + "-5d8f7"},
+		{name: "pod IP", got: o.Pods.Items[0].Status.PodIP, want: r.Alias("10.1.2.3")},
+		{name: "host", got: o.Ingresses.Items[0].Spec.Rules[0].Host, want: "host-2.example"},
+		{name: "load balancer", got: o.Services.Items[0].Status.LoadBalancer.Ingress[0].Hostname, want: "host-1.example"},
+		{
+			name: "ExternalName",
+			got:  o.Services.Items[1].Spec.ExternalName,
+			want: r.Alias("postgres") + "." + r.Alias("data") + ".svc.cluster.local",
+		},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %s, want %s", test.name, test.got, test.want)
+		}
+	}
+
+	content, err := json.Marshal(o)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	for _, leak := range []string{`"web`, "postgres", "data.", "shop.internal.corp", "amazonaws", "10.1.2.3", "10.0.0.10",
+		"shop-chart"} {
+		if strings.Contains(string(content), leak) {
+			t.Errorf("redacted objects contain %s:\n%s", leak, content)
+		}
+	}
+
+	if got := r.Aliases()[web]; got != "web" {
+		t.Errorf("alias %s maps to %s, want web", web, got)
+	}
+}
+
+func TestRedactHashed(t *testing.T) {
+	alias := func(salt string) string {
+		r, err := NewRedactor(RedactHashed, salt, "")
+		if err != nil {
+			t.Fatalf("NewRedactor() error = %v", err)
+		}
+
+		if _, err := r.Redact(redactFixture()); err != nil {
+			t.Fatalf("Redact() error = %v", err)
+		}
+
+		return r.Alias("web")
+	}
+
+	if alias("a") != alias("a") {
+		t.Errorf("hashed aliases differ across runs")
+	}
+
+	if alias("a") == alias("b") {
+		t.Errorf("hashed aliases don't depend on the salt")
+	}
+
+	if _, err := NewRedactor("scramble", "", ""); err == nil {
+		t.Errorf("NewRedactor() accepted an unknown mode")
+	}
+}
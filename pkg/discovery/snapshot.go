@@ -0,0 +1,58 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/go-version"
+)
+
+// snapshot is the JSON document discovered objects are saved to, to be drawn or compared later without the cluster.
+type snapshot struct {
+	Version string   `json:"version,omitempty"`
+	Objects *Objects `json:"objects"`
+}
+
+// SaveSnapshot writes discovered objects as JSON.
+func SaveSnapshot(w io.Writer, o *Objects) error {
+	s := snapshot{Objects: o}
+	if o.Version != nil {
+		s.Version = o.Version.String()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("encoding snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot reads objects saved by SaveSnapshot.
+func LoadSnapshot(r io.Reader) (*Objects, error) {
+	var s snapshot
+
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+
+	if s.Objects == nil {
+		return nil, fmt.Errorf("decoding snapshot: no objects")
+	}
+
+	if s.Version != "" {
+		v, err := version.NewVersion(s.Version)
+		if err != nil {
+			return nil, fmt.Errorf("getting snapshot version: %w", err)
+		}
+
+		s.Objects.Version = v
+	}
+
+	s.Objects.ensureLists()
+
+	return s.Objects, nil
+}
@@ -0,0 +1,64 @@
+package discovery
+
+import (
+	"context"
+	"io"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Source provides the objects of a namespace: the live cluster, a snapshot or manifests.
+type Source interface {
+	Objects(ctx context.Context, namespace string) (*Objects, error)
+}
+
+// SourceFunc adapts a function to a Source.
+type SourceFunc func(ctx context.Context, namespace string) (*Objects, error)
+
+// Objects calls the function.
+func (f SourceFunc) Objects(ctx context.Context, namespace string) (*Objects, error) {
+	return f(ctx, namespace)
+}
+
+// FromCluster discovers the objects of the cluster a configuration connects to.
+func FromCluster(config *rest.Config, opts ...Option) Source {
+	return SourceFunc(func(ctx context.Context, namespace string) (*Objects, error) {
+		k, err := NewDiscovery(ctx, config, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return k.GenerateAll(namespace)
+	})
+}
+
+// FromClient discovers the objects of a cluster using an existing client.
+func FromClient(client kubernetes.Interface, opts ...Option) Source {
+	return SourceFunc(func(ctx context.Context, namespace string) (*Objects, error) {
+		k := NewDiscoveryFromClient(ctx, client, opts...)
+
+		return k.GenerateAll(namespace)
+	})
+}
+
+// FromSnapshot reads the objects of a snapshot written by SaveSnapshot. The reader is consumed by the first call.
+func FromSnapshot(r io.Reader) Source {
+	return SourceFunc(func(context.Context, string) (*Objects, error) {
+		return LoadSnapshot(r)
+	})
+}
+
+// FromManifests reads the objects of YAML manifest files or directories, as LoadManifests does.
+func FromManifests(paths ...string) Source {
+	return SourceFunc(func(_ context.Context, namespace string) (*Objects, error) {
+		return LoadManifests(namespace, paths...)
+	})
+}
+
+// FromObjects provides objects already read.
+func FromObjects(o *Objects) Source {
+	return SourceFunc(func(context.Context, string) (*Objects, error) {
+		return o, nil
+	})
+}
@@ -0,0 +1,214 @@
+// Package flows reads network flow records, such as Hubble exports, and maps them to the pods and services of a
+// namespace to draw the calls observed between them.
+package flows
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxLineSize is the size of the longest Hubble flow read, as flows with HTTP headers can be large.
+const maxLineSize = 1024 * 1024
+
+// ErrUnknownFormat is returned when the format of a flow file is not recognized.
+var ErrUnknownFormat = errors.New("unknown flow format")
+
+// Endpoint is an end of a flow. Pod, Service and Namespace are only known when the exporter resolved them.
+type Endpoint struct {
+	IP        string
+	Namespace string
+	Pod       string
+	Service   string
+}
+
+// Record is a flow observed between two endpoints, Count times.
+type Record struct {
+	Source      Endpoint
+	Destination Endpoint
+	Port        int32
+	Count       int
+}
+
+// ReadFile reads the flow records of a file: a CSV file when its extension is .csv, or a Hubble JSON export
+// otherwise.
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading flows: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ReadCSV(f)
+	}
+
+	return ReadHubble(f)
+}
+
+// ReadCSV reads flow records from CSV columns: source IP, destination IP, destination port and an optional count,
+// defaulting to 1. A header line starting with src or source is skipped.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var records []Record
+
+	for line := 1; ; line++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV flows: %w", err)
+		}
+
+		if line == 1 && (strings.EqualFold(fields[0], "src") || strings.EqualFold(fields[0], "source")) {
+			continue
+		}
+
+		record, err := csvRecord(fields)
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV flows, line %d: %w", line, err)
+		}
+
+		records = append(records, record)
+	}
+}
+
+func csvRecord(fields []string) (Record, error) {
+	if len(fields) < 3 {
+		return Record{}, fmt.Errorf("%w: want src,dst,port[,count] columns, got %d", ErrUnknownFormat, len(fields))
+	}
+
+	port, err := strconv.ParseInt(fields[2], 10, 32)
+	if err != nil {
+		return Record{}, fmt.Errorf("parsing port: %w", err)
+	}
+
+	count := 1
+
+	if len(fields) > 3 && fields[3] != "" {
+		if count, err = strconv.Atoi(fields[3]); err != nil {
+			return Record{}, fmt.Errorf("parsing count: %w", err)
+		}
+	}
+
+	return Record{
+		Source:      Endpoint{IP: fields[0]},
+		Destination: Endpoint{IP: fields[1]},
+		Port:        int32(port),
+		Count:       count,
+	}, nil
+}
+
+type hubbleEndpoint struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+}
+
+type hubbleService struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type hubblePort struct {
+	DestinationPort int32 `json:"destination_port"`
+}
+
+type hubbleFlow struct {
+	Verdict string `json:"verdict"`
+	IP      struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	} `json:"IP"`
+	L4 struct {
+		TCP *hubblePort `json:"TCP"`
+		UDP *hubblePort `json:"UDP"`
+	} `json:"l4"`
+	Source             hubbleEndpoint `json:"source"`
+	Destination        hubbleEndpoint `json:"destination"`
+	DestinationService *hubbleService `json:"destination_service"`
+	IsReply            *bool          `json:"is_reply"`
+}
+
+// ReadHubble reads the flows of a Hubble JSON export, as written by hubble observe -o json, one flow per line. Reply
+// and dropped flows are skipped, so that each flow is a call from its source to its destination.
+func ReadHubble(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	var records []Record
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		// Exports either wrap each flow in a response, or list the flows themselves.
+		var wrapped struct {
+			Flow *hubbleFlow `json:"flow"`
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &wrapped); err != nil {
+			return nil, fmt.Errorf("reading Hubble flows, line %d: %w", line, err)
+		}
+
+		f := wrapped.Flow
+		if f == nil {
+			f = &hubbleFlow{}
+			if err := json.Unmarshal(scanner.Bytes(), f); err != nil {
+				return nil, fmt.Errorf("reading Hubble flows, line %d: %w", line, err)
+			}
+		}
+
+		if record, ok := hubbleRecord(*f); ok {
+			records = append(records, record)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading Hubble flows: %w", err)
+	}
+
+	return records, nil
+}
+
+func hubbleRecord(f hubbleFlow) (Record, bool) {
+	if (f.Verdict != "" && f.Verdict != "FORWARDED") || (f.IsReply != nil && *f.IsReply) || f.IP.Source == "" {
+		return Record{}, false
+	}
+
+	record := Record{
+		Source:      Endpoint{IP: f.IP.Source, Namespace: f.Source.Namespace, Pod: f.Source.PodName},
+		Destination: Endpoint{IP: f.IP.Destination, Namespace: f.Destination.Namespace, Pod: f.Destination.PodName},
+		Count:       1,
+	}
+
+	if f.DestinationService != nil {
+		record.Destination.Service = f.DestinationService.Name
+		if f.DestinationService.Namespace != "" {
+			record.Destination.Namespace = f.DestinationService.Namespace
+		}
+	}
+
+	switch {
+	case f.L4.TCP != nil:
+		record.Port = f.L4.TCP.DestinationPort
+	case f.L4.UDP != nil:
+		record.Port = f.L4.UDP.DestinationPort
+	}
+
+	return record, true
+}
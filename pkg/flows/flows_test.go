@@ -0,0 +1,97 @@
+package flows
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNamespace = "app"
+
+func TestReadHubble(t *testing.T) {
+	records, err := ReadFile("testdata/hubble.json")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	want := []Record{
+		{
+			Source:      Endpoint{IP: "10.0.1.10", Namespace: "app", Pod: "web-1"},
+			Destination: Endpoint{IP: "10.96.0.20", Namespace: "app", Pod: "api-1", Service: "api"},
+			Port:        8080,
+			Count:       1,
+		},
+		{
+			Source:      Endpoint{IP: "10.0.1.10", Namespace: "app", Pod: "web-1"},
+			Destination: Endpoint{IP: "10.0.2.30", Namespace: "kube-system", Pod: "coredns-1"},
+			Port:        53,
+			Count:       1,
+		},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ReadFile() = %+v, want %+v", records, want)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	input := "src,dst,port,count\n10.0.1.10,10.96.0.20,8080,42\n10.0.1.11, 10.0.1.12, 9090\n"
+
+	records, err := ReadCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+
+	want := []Record{
+		{Source: Endpoint{IP: "10.0.1.10"}, Destination: Endpoint{IP: "10.96.0.20"}, Port: 8080, Count: 42},
+		{Source: Endpoint{IP: "10.0.1.11"}, Destination: Endpoint{IP: "10.0.1.12"}, Port: 9090, Count: 1},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ReadCSV() = %+v, want %+v", records, want)
+	}
+
+	if _, err := ReadCSV(strings.NewReader("10.0.1.10,10.96.0.20\n")); err == nil {
+		t.Error("ReadCSV() succeeded without a port column")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	pod := func(name, ip string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Status:     corev1.PodStatus{PodIP: ip},
+		}
+	}
+
+	o := &discovery.Objects{
+		Pods: &corev1.PodList{Items: []corev1.Pod{pod("web-1", "10.0.1.10"), pod("api-1", "10.0.1.20")}},
+		Services: &corev1.ServiceList{Items: []corev1.Service{{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: testNamespace},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.20"},
+		}}},
+	}
+
+	records := []Record{
+		{Source: Endpoint{IP: "10.0.1.10"}, Destination: Endpoint{IP: "10.96.0.20"}, Port: 8080, Count: 40},
+		{Source: Endpoint{IP: "10.0.1.10"}, Destination: Endpoint{IP: "10.96.0.20"}, Port: 8080, Count: 2},
+		{Source: Endpoint{IP: "10.0.1.10"}, Destination: Endpoint{IP: "10.0.1.20"}, Port: 9090, Count: 1},
+		{Source: Endpoint{IP: "10.0.1.10"}, Destination: Endpoint{IP: "10.0.2.30"}, Port: 53, Count: 1},
+	}
+
+	calls, unresolved := Resolve(testNamespace, o, records)
+
+	want := []Call{
+		{From: Object{Kind: KindPod, Name: "web-1"}, To: Object{Kind: KindPod, Name: "api-1"}, Port: 9090, Count: 1},
+		{From: Object{Kind: KindPod, Name: "web-1"}, To: Object{Kind: KindService, Name: "api"}, Port: 8080, Count: 42},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Resolve() = %+v, want %+v", calls, want)
+	}
+
+	if unresolved != 1 {
+		t.Errorf("got %d unresolved records, want 1", unresolved)
+	}
+}
@@ -0,0 +1,168 @@
+package flows
+
+import (
+	"sort"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+const (
+	// KindPod is a call end resolved to a pod.
+	KindPod = "Pod"
+	// KindService is a call end resolved to a service.
+	KindService = "Service"
+)
+
+// Object is a pod or a service of the namespace.
+type Object struct {
+	Kind string
+	Name string
+}
+
+// Call is a call observed Count times from an object of the namespace to another one, on a port.
+type Call struct {
+	From  Object
+	To    Object
+	Port  int32
+	Count int
+}
+
+// resolver maps the ends of flows to the pods and services of a namespace.
+type resolver struct {
+	namespace string
+	pods      map[string]string
+	podIPs    map[string]string
+	services  map[string]string
+	serviceIP map[string]string
+}
+
+func newResolver(namespace string, o *discovery.Objects) resolver {
+	r := resolver{
+		namespace: namespace,
+		pods:      make(map[string]string),
+		podIPs:    make(map[string]string),
+		services:  make(map[string]string),
+		serviceIP: make(map[string]string),
+	}
+
+	for _, pod := range o.Pods.Items {
+		if pod.Namespace != namespace {
+			continue
+		}
+
+		r.pods[pod.Name] = pod.Name
+
+		// Pods on the host network share the IP of their node.
+		if pod.Spec.HostNetwork {
+			continue
+		}
+
+		for _, ip := range pod.Status.PodIPs {
+			r.podIPs[ip.IP] = pod.Name
+		}
+
+		if pod.Status.PodIP != "" {
+			r.podIPs[pod.Status.PodIP] = pod.Name
+		}
+	}
+
+	for _, svc := range o.Services.Items {
+		if svc.Namespace != namespace {
+			continue
+		}
+
+		r.services[svc.Name] = svc.Name
+
+		for _, ip := range append([]string{svc.Spec.ClusterIP}, svc.Spec.ClusterIPs...) {
+			if ip != "" && ip != "None" {
+				r.serviceIP[ip] = svc.Name
+			}
+		}
+	}
+
+	return r
+}
+
+// resolve maps the end of a flow to a service or a pod of the namespace, from the names resolved by the exporter,
+// or from its IP.
+func (r resolver) resolve(e Endpoint) (Object, bool) {
+	local := e.Namespace == "" || e.Namespace == r.namespace
+
+	if name, ok := r.services[e.Service]; ok && local {
+		return Object{Kind: KindService, Name: name}, true
+	}
+
+	if name, ok := r.serviceIP[e.IP]; ok {
+		return Object{Kind: KindService, Name: name}, true
+	}
+
+	if name, ok := r.podIPs[e.IP]; ok {
+		return Object{Kind: KindPod, Name: name}, true
+	}
+
+	if name, ok := r.pods[e.Pod]; ok && local {
+		return Object{Kind: KindPod, Name: name}, true
+	}
+
+	return Object{}, false
+}
+
+// Resolve maps the flow records to the pods and services of a namespace, and merges them into calls sorted by
+// source, destination and port. It also returns the number of records with an end outside of the namespace.
+func Resolve(namespace string, o *discovery.Objects, records []Record) ([]Call, int) {
+	r := newResolver(namespace, o)
+
+	type callKey struct {
+		from, to Object
+		port     int32
+	}
+
+	counts := make(map[callKey]int)
+	unresolved := 0
+
+	for _, record := range records {
+		from, fromOK := r.resolve(record.Source)
+		to, toOK := r.resolve(record.Destination)
+
+		if !fromOK || !toOK || from == to {
+			unresolved++
+
+			continue
+		}
+
+		counts[callKey{from: from, to: to, port: record.Port}] += record.Count
+	}
+
+	calls := make([]Call, 0, len(counts))
+	for k, count := range counts {
+		calls = append(calls, Call{From: k.from, To: k.to, Port: k.port, Count: count})
+	}
+
+	sort.Slice(calls, func(i, j int) bool {
+		a, b := calls[i], calls[j]
+
+		switch {
+		case a.From != b.From:
+			return a.From.Kind+"/"+a.From.Name < b.From.Kind+"/"+b.From.Name
+		case a.To != b.To:
+			return a.To.Kind+"/"+a.To.Name < b.To.Kind+"/"+b.To.Name
+		default:
+			return a.Port < b.Port
+		}
+	})
+
+	return calls, unresolved
+}
+
+// Rename returns the calls with the names of their ends renamed, such as with the aliases of a discovery.Redactor to
+// draw them on a redacted diagram.
+func Rename(calls []Call, rename func(string) string) []Call {
+	renamed := make([]Call, 0, len(calls))
+
+	for _, c := range calls {
+		c.From.Name, c.To.Name = rename(c.From.Name), rename(c.To.Name)
+		renamed = append(renamed, c)
+	}
+
+	return renamed
+}
@@ -0,0 +1,159 @@
+// Package lint checks the discovered objects of a namespace for topology problems, such as services without
+// endpoints or ingresses pointing to missing services.
+package lint
+
+import (
+	"sort"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+)
+
+// Severity tells how serious a finding is, using the SARIF levels.
+type Severity string
+
+const (
+	// SeverityError is a problem breaking the traffic to an application.
+	SeverityError Severity = "error"
+	// SeverityWarning is a problem likely breaking the traffic to an application.
+	SeverityWarning Severity = "warning"
+	// SeverityNote is a suspicious, but possibly intended, setup.
+	SeverityNote Severity = "note"
+)
+
+// Severities lists the severities, from the most to the least serious.
+func Severities() []Severity {
+	return []Severity{SeverityError, SeverityWarning, SeverityNote}
+}
+
+// AtLeast tells if a severity is as serious as another one.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() <= other.rank()
+}
+
+func (s Severity) rank() int {
+	for i, severity := range Severities() {
+		if s == severity {
+			return i
+		}
+	}
+
+	return len(Severities())
+}
+
+// Finding is a problem found on an object.
+type Finding struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Message   string   `json:"message"`
+}
+
+// Rule checks the objects of a namespace for a problem.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	// State tells if the rule checks the state of the cluster, that manifests don't have.
+	State bool
+	check func(namespace string, o *discovery.Objects) []Finding
+}
+
+// Rules lists the rules run by Run.
+func Rules() []Rule {
+	return []Rule{
+		{
+			ID:          "service-no-endpoints",
+			Description: "Services selecting pods should have ready endpoints.",
+			Severity:    SeverityWarning,
+			State:       true,
+			check:       servicesWithoutEndpoints,
+		},
+		{
+			ID:          "service-selector-matches-nothing",
+			Description: "Service selectors should match pods.",
+			Severity:    SeverityWarning,
+			check:       selectorsMatchingNothing,
+		},
+		{
+			ID:          "ingress-missing-service",
+			Description: "Ingress backends should point to existing services.",
+			Severity:    SeverityError,
+			check:       ingressesWithMissingServices,
+		},
+		{
+			ID:          "ingress-missing-port",
+			Description: "Ingress backends should point to ports of their service.",
+			Severity:    SeverityError,
+			check:       ingressesWithMissingPorts,
+		},
+		{
+			ID:          "deployment-unavailable",
+			Description: "Deployments should have available replicas.",
+			Severity:    SeverityError,
+			State:       true,
+			check:       unavailableDeployments,
+		},
+		{
+			ID:          "loadbalancer-pending",
+			Description: "LoadBalancer services should get an address.",
+			Severity:    SeverityWarning,
+			State:       true,
+			check:       pendingLoadBalancers,
+		},
+		{
+			ID:          "pvc-pending",
+			Description: "PersistentVolumeClaims should be bound.",
+			Severity:    SeverityWarning,
+			State:       true,
+			check:       pendingClaims,
+		},
+		{
+			ID:          "orphan-pod",
+			Description: "Pods should be managed by a controller.",
+			Severity:    SeverityNote,
+			check:       orphanPods,
+		},
+	}
+}
+
+// Run checks the objects of a namespace with every rule, and returns the findings sorted by object.
+func Run(namespace string, o *discovery.Objects) []Finding {
+	return run(namespace, o, Rules())
+}
+
+// RunStatic checks the objects of a namespace read from manifests, with the rules not checking the state of the
+// cluster.
+func RunStatic(namespace string, o *discovery.Objects) []Finding {
+	var rules []Rule
+
+	for _, r := range Rules() {
+		if !r.State {
+			rules = append(rules, r)
+		}
+	}
+
+	return run(namespace, o, rules)
+}
+
+func run(namespace string, o *discovery.Objects, rules []Rule) []Finding {
+	var findings []Finding
+
+	for _, r := range rules {
+		for _, f := range r.check(namespace, o) {
+			f.Rule, f.Severity, f.Namespace = r.ID, r.Severity, namespace
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Kind != findings[j].Kind {
+			return findings[i].Kind < findings[j].Kind
+		}
+
+		return findings[i].Name < findings[j].Name
+	})
+
+	return findings
+}
@@ -0,0 +1,169 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNamespace = "app"
+
+func meta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: testNamespace}
+}
+
+func testObjects() *discovery.Objects {
+	web := map[string]string{"app": "web"}
+	owner := []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d9f7c"}}
+
+	return &discovery.Objects{
+		Deployments: &appsv1.DeploymentList{Items: []appsv1.Deployment{
+			{ObjectMeta: meta("web"), Status: appsv1.DeploymentStatus{AvailableReplicas: 1}},
+			{ObjectMeta: meta("worker")},
+		}},
+		StatefulSets: &appsv1.StatefulSetList{},
+		DaemonSets:   &appsv1.DaemonSetList{},
+		Pods: &corev1.PodList{Items: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{
+				Name: "web-5d9f7c-x2x8j", Namespace: testNamespace, Labels: web, OwnerReferences: owner,
+			}},
+			{ObjectMeta: meta("debug")},
+		}},
+		Services: &corev1.ServiceList{Items: []corev1.Service{
+			{
+				ObjectMeta: meta("web"),
+				Spec: corev1.ServiceSpec{
+					Selector: web,
+					Type:     corev1.ServiceTypeLoadBalancer,
+					Ports:    []corev1.ServicePort{{Name: "http", Port: 80}},
+				},
+			},
+			{ObjectMeta: meta("api"), Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "api"}}},
+			{ObjectMeta: meta("headless")},
+		}},
+		Endpoints: &corev1.EndpointsList{Items: []corev1.Endpoints{
+			{
+				ObjectMeta: meta("web"),
+				Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+			},
+		}},
+		Ingresses: &networkingv1.IngressList{Items: []networkingv1.Ingress{
+			{
+				ObjectMeta: meta("web"),
+				Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+								Name: "web", Port: networkingv1.ServiceBackendPort{Name: "http"},
+							}}},
+							{Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+								Name: "web", Port: networkingv1.ServiceBackendPort{Number: 8080},
+							}}},
+							{Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+								Name: "admin", Port: networkingv1.ServiceBackendPort{Number: 80},
+							}}},
+						},
+					}},
+				}}},
+			},
+		}},
+		PersistentVolumeClaims: &corev1.PersistentVolumeClaimList{Items: []corev1.PersistentVolumeClaim{
+			{ObjectMeta: meta("data"), Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+			{ObjectMeta: meta("logs"), Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound}},
+		}},
+	}
+}
+
+func TestRun(t *testing.T) {
+	var got []string
+	for _, f := range Run(testNamespace, testObjects()) {
+		got = append(got, f.Rule+" "+f.Key())
+	}
+
+	want := []string{
+		"deployment-unavailable Deployment/app/worker",
+		"ingress-missing-service Ingress/app/web",
+		"ingress-missing-port Ingress/app/web",
+		"pvc-pending PersistentVolumeClaim/app/data",
+		"orphan-pod Pod/app/debug",
+		"service-selector-matches-nothing Service/app/api",
+		"service-no-endpoints Service/app/headless",
+		"loadbalancer-pending Service/app/web",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got findings %q, want %q", got, want)
+	}
+}
+
+func TestRunStatic(t *testing.T) {
+	o := testObjects()
+	o.Pods.Items = nil
+	o.Deployments.Items[1].Spec.Template.Labels = map[string]string{"app": "api"}
+
+	var got []string
+	for _, f := range RunStatic(testNamespace, o) {
+		got = append(got, f.Rule+" "+f.Key())
+	}
+
+	want := []string{
+		"ingress-missing-service Ingress/app/web",
+		"ingress-missing-port Ingress/app/web",
+		"service-selector-matches-nothing Service/app/web",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got findings %q, want %q", got, want)
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	if !SeverityError.AtLeast(SeverityWarning) || SeverityNote.AtLeast(SeverityWarning) {
+		t.Error("severities are not ordered from error to note")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	findings := Run(testNamespace, testObjects())
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, findings); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("decoding SARIF: %v", err)
+	}
+
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("got version %q and %d runs, want %q and 1 run", log.Version, len(log.Runs), sarifVersion)
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(Rules()) || len(run.Results) != len(findings) {
+		t.Errorf("got %d rules and %d results, want %d and %d",
+			len(run.Tool.Driver.Rules), len(run.Results), len(Rules()), len(findings))
+	}
+
+	location := run.Results[0].Locations[0].LogicalLocations[0]
+	if location.FullyQualifiedName != "Deployment/app/worker" || run.Results[0].Level != SeverityError {
+		t.Errorf("got first result %+v at %+v", run.Results[0], location)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, nil); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	if want := "0 errors, 0 warnings, 0 notes\n"; buf.String() != want {
+		t.Errorf("WriteText() = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,161 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "k8s-diagrams"
+	toolURI      = "https://github.com/trois-six/k8s-diagrams"
+)
+
+// Key identifies the object of a finding as Kind/namespace/name.
+func (f Finding) Key() string {
+	return f.Kind + "/" + f.Namespace + "/" + f.Name
+}
+
+// Summary counts the findings by severity.
+func Summary(findings []Finding) string {
+	counts := make(map[Severity]int)
+
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+
+	parts := make([]string, 0, len(Severities()))
+
+	for _, severity := range Severities() {
+		parts = append(parts, fmt.Sprintf("%d %ss", counts[severity], severity))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// WriteText writes the findings one per line, followed by their summary.
+func WriteText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%-7s %s %s/%s: %s\n", f.Severity, f.Rule, f.Kind, f.Name, f.Message); err != nil {
+			return fmt.Errorf("writing findings: %w", err)
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, Summary(findings)); err != nil {
+		return fmt.Errorf("writing findings: %w", err)
+	}
+
+	return nil
+}
+
+// WriteJSON writes the findings as a JSON array.
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+
+	return encode(w, findings)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level Severity `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     Severity        `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log, to be uploaded to code scanning tools.
+func WriteSARIF(w io.Writer, findings []Finding) error {
+	rules := make([]sarifRule, 0, len(Rules()))
+
+	for _, r := range Rules() {
+		rules = append(rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifConfiguration{Level: r.Severity},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+
+	for _, f := range findings {
+		results = append(results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   f.Severity,
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+				Name:               f.Name,
+				FullyQualifiedName: f.Key(),
+				Kind:               f.Kind,
+			}}}},
+		})
+	}
+
+	return encode(w, sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: toolName, InformationURI: toolURI, Rules: rules}},
+			Results: results,
+		}},
+	})
+}
+
+func encode(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encoding findings: %w", err)
+	}
+
+	return nil
+}
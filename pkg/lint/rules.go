@@ -0,0 +1,339 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/trois-six/k8s-diagrams/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	kindDeployment = "Deployment"
+	kindIngress    = "Ingress"
+	kindPod        = "Pod"
+	kindService    = "Service"
+	kindPVC        = "PersistentVolumeClaim"
+)
+
+func servicesWithoutEndpoints(namespace string, o *discovery.Objects) []Finding {
+	var findings []Finding
+
+	for _, svc := range o.Services.Items {
+		if svc.Namespace != namespace || svc.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+
+		// Selectors matching nothing are reported by their own rule.
+		if len(svc.Spec.Selector) > 0 && len(selectedPods(svc, o.Pods)) == 0 {
+			continue
+		}
+
+		if readyEndpoints(svc, o.Endpoints, o.EndpointSlices) == 0 {
+			findings = append(findings, Finding{
+				Kind:    kindService,
+				Name:    svc.Name,
+				Message: fmt.Sprintf("service %s has no ready endpoint", svc.Name),
+			})
+		}
+	}
+
+	return findings
+}
+
+// readyEndpoints counts the ready endpoints of a service, from its EndpointSlices when they were discovered, or from
+// its Endpoints otherwise.
+func readyEndpoints(svc corev1.Service, endpoints *corev1.EndpointsList, slices *discoveryv1.EndpointSliceList) int {
+	count := 0
+
+	if slices != nil {
+		for _, slice := range slices.Items {
+			if slice.Namespace != svc.Namespace || slice.Labels[discoveryv1.LabelServiceName] != svc.Name {
+				continue
+			}
+
+			for _, ep := range slice.Endpoints {
+				if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+					count++
+				}
+			}
+		}
+
+		return count
+	}
+
+	for _, ep := range endpoints.Items {
+		if ep.Namespace != svc.Namespace || ep.Name != svc.Name {
+			continue
+		}
+
+		for _, subset := range ep.Subsets {
+			count += len(subset.Addresses)
+		}
+	}
+
+	return count
+}
+
+func selectedPods(svc corev1.Service, pods *corev1.PodList) []string {
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	var selected []string
+
+	for _, pod := range pods.Items {
+		if pod.Namespace == svc.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+			selected = append(selected, pod.Name)
+		}
+	}
+
+	return selected
+}
+
+// selectsTemplate tells if a service selects the pod template of a workload, as manifests don't declare pods.
+func selectsTemplate(svc corev1.Service, o *discovery.Objects) bool {
+	var templates []map[string]string
+
+	for _, v := range o.Deployments.Items {
+		if v.Namespace == svc.Namespace {
+			templates = append(templates, v.Spec.Template.Labels)
+		}
+	}
+
+	for _, v := range o.StatefulSets.Items {
+		if v.Namespace == svc.Namespace {
+			templates = append(templates, v.Spec.Template.Labels)
+		}
+	}
+
+	for _, v := range o.DaemonSets.Items {
+		if v.Namespace == svc.Namespace {
+			templates = append(templates, v.Spec.Template.Labels)
+		}
+	}
+
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	for _, l := range templates {
+		if selector.Matches(labels.Set(l)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func selectorsMatchingNothing(namespace string, o *discovery.Objects) []Finding {
+	var findings []Finding
+
+	for _, svc := range o.Services.Items {
+		if svc.Namespace != namespace || len(svc.Spec.Selector) == 0 || svc.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+
+		if len(selectedPods(svc, o.Pods)) == 0 && !selectsTemplate(svc, o) {
+			findings = append(findings, Finding{
+				Kind:    kindService,
+				Name:    svc.Name,
+				Message: fmt.Sprintf("selector %s of service %s matches no pod", labels.Set(svc.Spec.Selector), svc.Name),
+			})
+		}
+	}
+
+	return findings
+}
+
+// ingressServiceBackends lists the service backends of an ingress, including its default backend.
+func ingressServiceBackends(ing networkingv1.Ingress) []networkingv1.IngressServiceBackend {
+	var backends []networkingv1.IngressServiceBackend
+
+	if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil {
+		backends = append(backends, *ing.Spec.DefaultBackend.Service)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				backends = append(backends, *path.Backend.Service)
+			}
+		}
+	}
+
+	return backends
+}
+
+func findService(namespace, name string, services *corev1.ServiceList) (corev1.Service, bool) {
+	for _, svc := range services.Items {
+		if svc.Namespace == namespace && svc.Name == name {
+			return svc, true
+		}
+	}
+
+	return corev1.Service{}, false
+}
+
+func ingressesWithMissingServices(namespace string, o *discovery.Objects) []Finding {
+	var findings []Finding
+
+	for _, ing := range o.Ingresses.Items {
+		if ing.Namespace != namespace {
+			continue
+		}
+
+		reported := make(map[string]bool)
+
+		for _, backend := range ingressServiceBackends(ing) {
+			if _, ok := findService(namespace, backend.Name, o.Services); ok || reported[backend.Name] {
+				continue
+			}
+
+			reported[backend.Name] = true
+
+			findings = append(findings, Finding{
+				Kind:    kindIngress,
+				Name:    ing.Name,
+				Message: fmt.Sprintf("ingress %s points to missing service %s", ing.Name, backend.Name),
+			})
+		}
+	}
+
+	return findings
+}
+
+func ingressesWithMissingPorts(namespace string, o *discovery.Objects) []Finding {
+	var findings []Finding
+
+	for _, ing := range o.Ingresses.Items {
+		if ing.Namespace != namespace {
+			continue
+		}
+
+		reported := make(map[string]bool)
+
+		for _, backend := range ingressServiceBackends(ing) {
+			svc, ok := findService(namespace, backend.Name, o.Services)
+			if !ok || hasPort(svc, backend.Port) {
+				continue
+			}
+
+			port := backend.Port.Name
+			if port == "" {
+				port = fmt.Sprint(backend.Port.Number)
+			}
+
+			if reported[backend.Name+":"+port] {
+				continue
+			}
+
+			reported[backend.Name+":"+port] = true
+
+			findings = append(findings, Finding{
+				Kind:    kindIngress,
+				Name:    ing.Name,
+				Message: fmt.Sprintf("ingress %s points to missing port %s of service %s", ing.Name, port, backend.Name),
+			})
+		}
+	}
+
+	return findings
+}
+
+func hasPort(svc corev1.Service, port networkingv1.ServiceBackendPort) bool {
+	// ExternalName services have no port to check.
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return true
+	}
+
+	for _, p := range svc.Spec.Ports {
+		if (port.Name != "" && p.Name == port.Name) || (port.Name == "" && p.Port == port.Number) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unavailableDeployments(namespace string, o *discovery.Objects) []Finding {
+	var findings []Finding
+
+	for _, v := range o.Deployments.Items {
+		if v.Namespace != namespace || (v.Spec.Replicas != nil && *v.Spec.Replicas == 0) {
+			continue
+		}
+
+		if v.Status.AvailableReplicas == 0 {
+			findings = append(findings, Finding{
+				Kind:    kindDeployment,
+				Name:    v.Name,
+				Message: fmt.Sprintf("deployment %s has no available replica", v.Name),
+			})
+		}
+	}
+
+	return findings
+}
+
+func pendingLoadBalancers(namespace string, o *discovery.Objects) []Finding {
+	var findings []Finding
+
+	for _, svc := range o.Services.Items {
+		if svc.Namespace != namespace || svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+
+		if len(svc.Status.LoadBalancer.Ingress) == 0 {
+			findings = append(findings, Finding{
+				Kind:    kindService,
+				Name:    svc.Name,
+				Message: fmt.Sprintf("LoadBalancer service %s has no address", svc.Name),
+			})
+		}
+	}
+
+	return findings
+}
+
+func pendingClaims(namespace string, o *discovery.Objects) []Finding {
+	if o.PersistentVolumeClaims == nil {
+		return nil
+	}
+
+	var findings []Finding
+
+	for _, pvc := range o.PersistentVolumeClaims.Items {
+		if pvc.Namespace != namespace || pvc.Status.Phase != corev1.ClaimPending {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Kind:    kindPVC,
+			Name:    pvc.Name,
+			Message: fmt.Sprintf("PersistentVolumeClaim %s is pending", pvc.Name),
+		})
+	}
+
+	return findings
+}
+
+func orphanPods(namespace string, o *discovery.Objects) []Finding {
+	var findings []Finding
+
+	for _, pod := range o.Pods.Items {
+		if pod.Namespace != namespace || len(pod.OwnerReferences) > 0 {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Kind:    kindPod,
+			Name:    pod.Name,
+			Message: fmt.Sprintf("pod %s is not managed by any controller", pod.Name),
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,278 @@
+// Package metrics reads the traffic between the ingresses, services and pods of a namespace from a Prometheus
+// compatible HTTP API.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const defaultWindow = "5m"
+
+// Queries are the PromQL queries reading the traffic of a namespace. $namespace and $window are replaced by the
+// namespace and the rate window. Service queries return one sample per service and pod labels, ingress queries one
+// sample per ingress and service labels. Rates are in requests per second, and latencies in seconds.
+type Queries struct {
+	ServiceRequests string
+	ServiceErrors   string
+	ServiceLatency  string
+	IngressRequests string
+	IngressErrors   string
+	IngressLatency  string
+}
+
+// DefaultQueries reads the service traffic from the http_requests_total and http_request_duration_seconds metrics of
+// the pods, labeled with their service and pod by the Prometheus operator, and the ingress traffic from the metrics of
+// the NGINX ingress controller.
+func DefaultQueries() Queries {
+	return Queries{
+		ServiceRequests: `sum by (service, pod) (rate(http_requests_total{namespace="$namespace"}[$window]))`,
+		ServiceErrors:   `sum by (service, pod) (rate(http_requests_total{namespace="$namespace",code=~"5.."}[$window]))`,
+		ServiceLatency: `histogram_quantile(0.95, sum by (service, pod, le) ` +
+			`(rate(http_request_duration_seconds_bucket{namespace="$namespace"}[$window])))`,
+		IngressRequests: `sum by (ingress, service) ` +
+			`(rate(nginx_ingress_controller_requests{exported_namespace="$namespace"}[$window]))`,
+		IngressErrors: `sum by (ingress, service) ` +
+			`(rate(nginx_ingress_controller_requests{exported_namespace="$namespace",status=~"5.."}[$window]))`,
+		IngressLatency: `histogram_quantile(0.95, sum by (ingress, service, le) ` +
+			`(rate(nginx_ingress_controller_request_duration_seconds_bucket{exported_namespace="$namespace"}[$window])))`,
+	}
+}
+
+// Stats is the traffic flowing through a link.
+type Stats struct {
+	// RequestRate is the number of requests per second.
+	RequestRate float64
+	// ErrorRate is the number of failed requests per second.
+	ErrorRate float64
+	// Latency is the 95th percentile of the request durations.
+	Latency time.Duration
+}
+
+// Link is a link between two objects, identified by their names.
+type Link struct {
+	From string
+	To   string
+}
+
+// Traffic is the traffic flowing from the ingresses to their services, and from the services to their pods.
+type Traffic struct {
+	Ingresses map[Link]Stats
+	Services  map[Link]Stats
+}
+
+// Rename returns the traffic with the names of the ends of its links renamed, such as with the aliases of a
+// discovery.Redactor to draw it on a redacted diagram.
+func (t Traffic) Rename(rename func(string) string) Traffic {
+	links := func(stats map[Link]Stats) map[Link]Stats {
+		if stats == nil {
+			return nil
+		}
+
+		renamed := make(map[Link]Stats, len(stats))
+		for l, s := range stats {
+			renamed[Link{From: rename(l.From), To: rename(l.To)}] = s
+		}
+
+		return renamed
+	}
+
+	return Traffic{Ingresses: links(t.Ingresses), Services: links(t.Services)}
+}
+
+// Client reads the traffic of a namespace from a Prometheus compatible HTTP API.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	window     string
+	queries    Queries
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client sending the queries.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithWindow sets the range the rates are computed over, such as 5m.
+func WithWindow(window string) Option {
+	return func(c *Client) {
+		c.window = window
+	}
+}
+
+// WithQueries replaces the default queries, for applications exposing other metrics.
+func WithQueries(queries Queries) Option {
+	return func(c *Client) {
+		c.queries = queries
+	}
+}
+
+// NewClient initialize a client of the Prometheus HTTP API served at a base URL.
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		url:        strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		window:     defaultWindow,
+		queries:    DefaultQueries(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Traffic reads the traffic of a namespace.
+func (c *Client) Traffic(ctx context.Context, namespace string) (Traffic, error) {
+	services, err := c.stats(ctx, namespace, linkQueries{
+		from:     "service",
+		to:       "pod",
+		requests: c.queries.ServiceRequests,
+		errors:   c.queries.ServiceErrors,
+		latency:  c.queries.ServiceLatency,
+	})
+	if err != nil {
+		return Traffic{}, err
+	}
+
+	ingresses, err := c.stats(ctx, namespace, linkQueries{
+		from:     "ingress",
+		to:       "service",
+		requests: c.queries.IngressRequests,
+		errors:   c.queries.IngressErrors,
+		latency:  c.queries.IngressLatency,
+	})
+	if err != nil {
+		return Traffic{}, err
+	}
+
+	return Traffic{Ingresses: ingresses, Services: services}, nil
+}
+
+// linkQueries are the queries of one kind of links, whose ends are read from the from and to labels of the samples.
+type linkQueries struct {
+	from     string
+	to       string
+	requests string
+	errors   string
+	latency  string
+}
+
+// stats runs the request, error and latency queries of links, and merges their samples by link.
+func (c *Client) stats(ctx context.Context, namespace string, lq linkQueries) (map[Link]Stats, error) {
+	stats := make(map[Link]Stats)
+
+	set := []struct {
+		query string
+		apply func(*Stats, float64)
+	}{
+		{lq.requests, func(s *Stats, v float64) { s.RequestRate = v }},
+		{lq.errors, func(s *Stats, v float64) { s.ErrorRate = v }},
+		{lq.latency, func(s *Stats, v float64) { s.Latency = time.Duration(v * float64(time.Second)) }},
+	}
+
+	for _, q := range set {
+		if q.query == "" {
+			continue
+		}
+
+		samples, err := c.query(ctx, strings.NewReplacer("$namespace", namespace, "$window", c.window).Replace(q.query))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sample := range samples {
+			link := Link{From: sample.Metric[lq.from], To: sample.Metric[lq.to]}
+			if link.From == "" || link.To == "" || math.IsNaN(sample.Value) {
+				continue
+			}
+
+			s := stats[link]
+			q.apply(&s, sample.Value)
+			stats[link] = s
+		}
+	}
+
+	return stats, nil
+}
+
+type sample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// query runs an instant query, and returns the samples of its vector result.
+func (c *Client) query(ctx context.Context, query string) ([]sample, error) {
+	log.Debug().Msgf("Querying Prometheus: %s", query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.url+"/api/v1/query?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating query: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying Prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var r queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("decoding Prometheus response (%s): %w", resp.Status, err)
+	}
+
+	if r.Status != "success" {
+		return nil, fmt.Errorf("querying Prometheus: %s: %s", r.ErrorType, r.Error)
+	}
+
+	if r.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("querying Prometheus: unexpected %s result, want a vector", r.Data.ResultType)
+	}
+
+	samples := make([]sample, 0, len(r.Data.Result))
+
+	for _, result := range r.Data.Result {
+		value, ok := result.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("querying Prometheus: unexpected sample value %v", result.Value[1])
+		}
+
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing sample value: %w", err)
+		}
+
+		samples = append(samples, sample{Metric: result.Metric, Value: v})
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubPrometheus answers the queries with the vector of the first fragment they contain.
+func stubPrometheus(t *testing.T, vectors map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		query := r.URL.Query().Get("query")
+		if !strings.Contains(query, `"app"`) || !strings.Contains(query, "[1m]") {
+			t.Errorf("query %q doesn't use the namespace and window", query)
+		}
+
+		result := "[]"
+
+		for fragment, vector := range vectors {
+			if strings.Contains(query, fragment) {
+				result = vector
+			}
+		}
+
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":%s}}`, result)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTraffic(t *testing.T) {
+	server := stubPrometheus(t, map[string]string{
+		`http_requests_total{namespace="app"}`: `[
+			{"metric":{"service":"web","pod":"web-1"},"value":[1620000000,"12.5"]},
+			{"metric":{"service":"web","pod":"web-2"},"value":[1620000000,"7.5"]}
+		]`,
+		`code=~"5.."`: `[{"metric":{"service":"web","pod":"web-1"},"value":[1620000000,"0.5"]}]`,
+		`http_request_duration_seconds_bucket`: `[
+			{"metric":{"service":"web","pod":"web-1"},"value":[1620000000,"0.035"]},
+			{"metric":{"service":"web","pod":"web-2"},"value":[1620000000,"NaN"]}
+		]`,
+		`nginx_ingress_controller_requests{exported_namespace="app"}`: `[
+			{"metric":{"ingress":"web","service":"web"},"value":[1620000000,"20"]}
+		]`,
+	})
+
+	got, err := NewClient(server.URL+"/", WithWindow("1m")).Traffic(context.Background(), "app")
+	if err != nil {
+		t.Fatalf("Traffic() error = %v", err)
+	}
+
+	want := Traffic{
+		Ingresses: map[Link]Stats{{From: "web", To: "web"}: {RequestRate: 20}},
+		Services: map[Link]Stats{
+			{From: "web", To: "web-1"}: {RequestRate: 12.5, ErrorRate: 0.5, Latency: 35 * time.Millisecond},
+			{From: "web", To: "web-2"}: {RequestRate: 7.5},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Traffic() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTrafficError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Traffic(context.Background(), "app")
+	if err == nil || !strings.Contains(err.Error(), "bad_data: parse error") {
+		t.Errorf("Traffic() error = %v, want the Prometheus error", err)
+	}
+}